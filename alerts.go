@@ -0,0 +1,126 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// defaultAlertTemplate is used when -alert-template is unset or invalid.
+const defaultAlertTemplate = `{{.Server.Name}} ({{.Server.Host}}:{{.Server.Port}}) is {{.Status}} (was {{.Previous}})` +
+	`{{if .Error}}: {{.Error}}{{end}}{{if .Downtime}}, down for {{.Downtime}}{{end}}` +
+	`{{range $key, $value := .Annotations}} [{{$key}}: {{$value}}]{{end}}`
+
+// Alert is a confirmed state change of a server, as passed to notification
+// templates and notifiers.
+type Alert struct {
+	Server    ServerConfig  `json:"server"`
+	Status    string        `json:"status"`
+	Previous  string        `json:"previous"`
+	Error     string        `json:"error,omitempty"`
+	Downtime  time.Duration `json:"downtime,omitempty"` // on recovery: how long it was down
+	Timestamp time.Time     `json:"timestamp"`
+	Message   string        `json:"message"` // rendered from the alert template
+
+	Annotations map[string]string `json:"annotations,omitempty"` // from the server config
+}
+
+// notifier delivers alerts to an external channel.
+type notifier interface {
+	Notify(alert Alert) error
+}
+
+// parseAlertTemplate loads the -alert-template value, either inline text or
+// "@path" to read it from a file, and checks that it renders against a
+// sample alert so mistakes surface at startup rather than mid-incident.
+func parseAlertTemplate(spec string) (*template.Template, error) {
+	text := spec
+	if strings.HasPrefix(spec, "@") {
+		data, err := os.ReadFile(strings.TrimPrefix(spec, "@"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read alert template: %v", err)
+		}
+		text = string(data)
+	}
+
+	tmpl, err := template.New("alert").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid alert template: %v", err)
+	}
+
+	sample := Alert{
+		Server:    ServerConfig{Name: "example", Host: "example.com", Port: 443, Protocol: "https", Timeout: 5},
+		Status:    "UP",
+		Previous:  "DOWN",
+		Error:     "connection refused",
+		Downtime:  time.Minute,
+		Timestamp: time.Now(),
+
+		Annotations: map[string]string{"runbook": "https://example.com/runbook"},
+	}
+	if err := tmpl.Execute(&bytes.Buffer{}, sample); err != nil {
+		return nil, fmt.Errorf("invalid alert template: %v", err)
+	}
+	return tmpl, nil
+}
+
+func mustDefaultAlertTemplate() *template.Template {
+	return template.Must(template.New("alert").Parse(defaultAlertTemplate))
+}
+
+// sendAlert renders the alert message and hands it to every notifier in the
+// background, so slow notifiers never hold up a check round.
+func (m *Monitor) sendAlert(alert Alert) {
+	var message bytes.Buffer
+	if err := m.alertTemplate.Execute(&message, alert); err != nil {
+		message.Reset()
+		mustDefaultAlertTemplate().Execute(&message, alert)
+	}
+	alert.Server = alert.Server.redacted()
+	alert.Message = strings.TrimSpace(message.String())
+
+	for _, n := range m.notifiers {
+		go func(n notifier) {
+			if err := n.Notify(alert); err != nil {
+				fmt.Fprintf(m.out, "Notification failed: %v\n", err)
+			}
+		}(n)
+	}
+}
+
+// webhookNotifier posts alerts as JSON. The rendered message is sent as
+// "text", so Slack and compatible incoming webhooks work out of the box.
+type webhookNotifier struct {
+	url    string
+	client *http.Client
+}
+
+func newWebhookNotifier(url string) *webhookNotifier {
+	return &webhookNotifier{url: url, client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+func (w *webhookNotifier) Notify(alert Alert) error {
+	payload := struct {
+		Text string `json:"text"`
+		Alert
+	}{alert.Message, alert}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	resp, err := w.client.Post(w.url, "application/json", bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("webhook: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook: HTTP %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// defaultBaselineSlowdown is how much slower than in the -baseline report,
+// in percent, a server may respond before it counts as a regression.
+const defaultBaselineSlowdown = 50
+
+// loadBaseline reads a known-good -report file and indexes its results by
+// server name.
+func loadBaseline(filename string) (map[string]HealthResult, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read baseline: %v", err)
+	}
+	var report Report
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, fmt.Errorf("failed to parse baseline %s: %v", filename, err)
+	}
+
+	// Reports written with -group-by list their results per group
+	results := report.Results
+	for _, group := range report.Groups {
+		results = append(results, group.Results...)
+	}
+	baseline := make(map[string]HealthResult, len(results))
+	for _, result := range results {
+		baseline[result.Server.Name] = result
+	}
+	return baseline, nil
+}
+
+// regression describes how result got worse than the same server in the
+// -baseline report: UP (or DEGRADED) there but DOWN now, or slower by more
+// than -baseline-slowdown. It returns "" for no regression, including for
+// servers the baseline doesn't know.
+func (m *Monitor) regression(result HealthResult) string {
+	base, ok := m.baseline[result.Server.Name]
+	if !ok || !base.up() {
+		return ""
+	}
+	if result.Status == "DOWN" {
+		return fmt.Sprintf("was %s in baseline, now DOWN", base.Status)
+	}
+	if !result.up() || base.ResponseTime <= 0 {
+		return ""
+	}
+	slowdown := float64(result.ResponseTime-base.ResponseTime) / float64(base.ResponseTime) * 100
+	if slowdown > m.baselineSlowdown {
+		return fmt.Sprintf("latency %dms vs %dms in baseline (+%.0f%%)",
+			result.ResponseTime, base.ResponseTime, slowdown)
+	}
+	return ""
+}
+
+// countRegressions counts the results flagged against the -baseline.
+func countRegressions(results []HealthResult) int {
+	n := 0
+	for _, result := range results {
+		if result.Regression != "" {
+			n++
+		}
+	}
+	return n
+}
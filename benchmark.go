@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"runtime"
+	"sort"
+	"time"
+)
+
+// RunBenchmark runs check rounds back to back for duration and reports the
+// monitor's own throughput, the latency distribution of the checks and the
+// peak goroutine count, to help size concurrency for large fleets.
+func (m *Monitor) RunBenchmark(duration time.Duration) {
+	fmt.Printf("Benchmarking %d servers for %v (max concurrent checks: %d)...\n",
+		len(m.servers), duration, m.maxConcurrency)
+
+	out := m.out
+	m.out = io.Discard
+	defer func() { m.out = out }()
+
+	// Sample the goroutine count while the rounds run
+	done := make(chan struct{})
+	peak := make(chan int)
+	go func() {
+		highest := runtime.NumGoroutine()
+		ticker := time.NewTicker(5 * time.Millisecond)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if n := runtime.NumGoroutine(); n > highest {
+					highest = n
+				}
+			case <-done:
+				peak <- highest
+				return
+			}
+		}
+	}()
+
+	var latencies []int64
+	rounds, down := 0, 0
+	start := time.Now()
+	for time.Since(start) < duration {
+		for _, result := range m.RunCheck() {
+			latencies = append(latencies, result.ResponseTime)
+			if result.Status == "DOWN" {
+				down++
+			}
+		}
+		rounds++
+	}
+	elapsed := time.Since(start)
+	close(done)
+
+	fmt.Printf("Rounds:      %d in %v\n", rounds, elapsed.Round(time.Millisecond))
+	fmt.Printf("Checks:      %d (%d DOWN)\n", len(latencies), down)
+	fmt.Printf("Throughput:  %.1f checks/sec, %.2f rounds/sec\n",
+		float64(len(latencies))/elapsed.Seconds(), float64(rounds)/elapsed.Seconds())
+	if len(latencies) > 0 {
+		sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+		at := func(p float64) int64 {
+			return latencies[int(p*float64(len(latencies)-1))]
+		}
+		fmt.Printf("Latency:     min=%dms p50=%dms p90=%dms p99=%dms max=%dms\n",
+			latencies[0], at(0.50), at(0.90), at(0.99), latencies[len(latencies)-1])
+	}
+	fmt.Printf("Goroutines:  peak %d\n", <-peak)
+}
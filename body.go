@@ -0,0 +1,169 @@
+package main
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// acceptEncoding is sent with HTTP checks so compressed health endpoints
+// answer the same way they would for a browser.
+const acceptEncoding = "gzip, deflate"
+
+// responseBody is what an HTTP check read of a response body.
+type responseBody struct {
+	data       []byte
+	compressed bool // gzip/deflate encoded on the wire
+	truncated  bool // longer than the -max-body-bytes limit
+}
+
+// readLimited reads up to limit bytes from r, reporting whether there was
+// more to read.
+func readLimited(r io.Reader, limit int64) ([]byte, bool, error) {
+	data, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if int64(len(data)) > limit {
+		return data[:limit], true, err
+	}
+	return data, false, err
+}
+
+// readBody reads up to limit bytes of the response body, decompressing
+// gzip and deflate encodings first; the limit applies both to the bytes
+// read off the wire and to the decompressed body.
+func readBody(resp *http.Response, limit int64) (responseBody, error) {
+	encoding := strings.ToLower(strings.TrimSpace(resp.Header.Get("Content-Encoding")))
+	raw, truncated, err := readLimited(resp.Body, limit)
+	body := responseBody{data: raw, truncated: truncated}
+	if err != nil {
+		return body, err
+	}
+
+	var decoded io.Reader
+	switch encoding {
+	case "", "identity":
+		return body, nil
+	case "gzip", "x-gzip":
+		body.compressed = true
+		zr, err := gzip.NewReader(bytes.NewReader(raw))
+		if err != nil {
+			return body, fmt.Errorf("invalid gzip body: %v", err)
+		}
+		defer zr.Close()
+		decoded = zr
+	case "deflate":
+		// "deflate" is meant to be zlib-wrapped, but some servers send a
+		// raw deflate stream; try zlib first and fall back to raw
+		body.compressed = true
+		if zr, err := zlib.NewReader(bytes.NewReader(raw)); err == nil {
+			defer zr.Close()
+			decoded = zr
+		} else {
+			decoded = flate.NewReader(bytes.NewReader(raw))
+		}
+	default:
+		// Unknown encodings are matched as-is
+		body.compressed = true
+		return body, nil
+	}
+
+	data, more, err := readLimited(decoded, limit)
+	body.data = data
+	body.truncated = body.truncated || more
+	if err != nil && !(truncated && errors.Is(err, io.ErrUnexpectedEOF)) {
+		// A stream cut off by the limit ends early; anything else is corrupt
+		return body, fmt.Errorf("failed to decompress %s body: %v", encoding, err)
+	}
+	return body, nil
+}
+
+// matchJSON decodes body as JSON and checks that each dotted path in expect
+// (e.g. "status" or "checks.0.state") holds the expected value. Scalars are
+// compared by their JSON text, with strings unquoted.
+func matchJSON(body []byte, expect map[string]string) error {
+	var doc interface{}
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return fmt.Errorf("body is not JSON: %v", err)
+	}
+
+	paths := make([]string, 0, len(expect))
+	for path := range expect {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		value, ok := lookupJSON(doc, path)
+		if !ok {
+			return fmt.Errorf("%s: field not found", path)
+		}
+		if got := jsonText(value); got != expect[path] {
+			return fmt.Errorf("%s: expected %q, got %q", path, expect[path], got)
+		}
+	}
+	return nil
+}
+
+func lookupJSON(doc interface{}, path string) (interface{}, bool) {
+	for _, key := range strings.Split(path, ".") {
+		switch node := doc.(type) {
+		case map[string]interface{}:
+			value, ok := node[key]
+			if !ok {
+				return nil, false
+			}
+			doc = value
+		case []interface{}:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(node) {
+				return nil, false
+			}
+			doc = node[i]
+		default:
+			return nil, false
+		}
+	}
+	return doc, true
+}
+
+func jsonText(value interface{}) string {
+	if s, ok := value.(string); ok {
+		return s
+	}
+	data, _ := json.Marshal(value)
+	return string(data)
+}
+
+// matchHeaders checks that each header in expect is present in the
+// response and, unless the expected value is empty, has exactly that value.
+func matchHeaders(header http.Header, expect map[string]string) error {
+	names := make([]string, 0, len(expect))
+	for name := range expect {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		values, ok := header[http.CanonicalHeaderKey(name)]
+		if !ok {
+			return fmt.Errorf("%s: header missing", name)
+		}
+		want := expect[name]
+		if want == "" {
+			continue
+		}
+		got := strings.Join(values, ", ")
+		if got != want {
+			return fmt.Errorf("%s: expected %q, got %q", name, want, got)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package main
+
+import "time"
+
+// TimeBudget is how a round fared against -healthcheck-timeout-budget.
+type TimeBudget struct {
+	BudgetMs   int64 `json:"budget_ms"`    // the whole round's budget
+	PerCheckMs int64 `json:"per_check_ms"` // timeout each check was given
+	ElapsedMs  int64 `json:"elapsed_ms"`   // how long the checks actually took
+}
+
+// budgetWaves is how many checks a server in the busiest pool waits behind:
+// with n servers sharing a limit of c slots, the pool needs ceil(n/c)
+// waves of checks. A dependent only starts once its parent is done, so the
+// longest depends_on chain adds a wave for each parent above its last
+// server.
+func (m *Monitor) budgetWaves(servers []ServerConfig) int {
+	waves := 1
+	for _, pool := range groupByPool(servers) {
+		limit := m.poolLimit(pool[0].Pool)
+		waves = max(waves, (len(pool)+limit-1)/limit)
+	}
+	return waves + dependencyDepth(servers)
+}
+
+// dependencyDepth is the number of parents above the most deeply nested
+// server. Parents outside the round don't count, as they don't hold up
+// their dependents.
+func dependencyDepth(servers []ServerConfig) int {
+	parents := make(map[string]string, len(servers))
+	for _, server := range servers {
+		parents[server.Name] = server.DependsOn
+	}
+	deepest := 0
+	for _, server := range servers {
+		depth := 0
+		for parent := server.DependsOn; depth < len(servers); parent = parents[parent] {
+			if _, ok := parents[parent]; !ok {
+				break
+			}
+			depth++
+		}
+		deepest = max(deepest, depth)
+	}
+	return deepest
+}
+
+// budgetPerCheck divides the timeout budget across the waves of checks the
+// round needs, so the round fits the budget even if every check times
+// out. A server's own, shorter timeout still applies.
+func (m *Monitor) budgetPerCheck(servers []ServerConfig) time.Duration {
+	return m.timeoutBudget / time.Duration(m.budgetWaves(servers))
+}
+
+// roundBudget compares a round's results against the budget: they ran from
+// the earliest check's start to the latest one's end.
+func (m *Monitor) roundBudget(results []HealthResult) *TimeBudget {
+	budget := &TimeBudget{BudgetMs: m.timeoutBudget.Milliseconds()}
+	var first, last time.Time
+	for _, result := range results {
+		budget.PerCheckMs = max(budget.PerCheckMs, result.BudgetMs)
+		start := result.Timestamp.Add(-time.Duration(result.ResponseTime) * time.Millisecond)
+		if first.IsZero() || start.Before(first) {
+			first = start
+		}
+		if result.Timestamp.After(last) {
+			last = result.Timestamp
+		}
+	}
+	budget.ElapsedMs = last.Sub(first).Milliseconds()
+	return budget
+}
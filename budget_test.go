@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// testHTTPServer returns an http check of a server that answers after
+// delay, or never if delay is negative.
+func testHTTPServer(t *testing.T, name string, delay time.Duration) ServerConfig {
+	t.Helper()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if delay < 0 {
+			<-r.Context().Done()
+			return
+		}
+		time.Sleep(delay)
+		fmt.Fprintln(w, "ok")
+	}))
+	t.Cleanup(ts.Close)
+	u, err := url.Parse(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	port, _ := strconv.Atoi(u.Port())
+	return ServerConfig{Name: name, Host: u.Hostname(), Port: port, Protocol: "http", Timeout: 10}
+}
+
+// TestTimeoutBudgetWithDependencies runs a chain whose parent is UP only
+// just within its share of the budget and whose dependent then times out:
+// the two run one after the other, and must still fit the budget together.
+func TestTimeoutBudgetWithDependencies(t *testing.T) {
+	parent := testHTTPServer(t, "db", 600*time.Millisecond)
+	child := testHTTPServer(t, "api", -1)
+	child.DependsOn = parent.Name
+
+	m := NewMonitor()
+	m.out = io.Discard
+	m.timeoutBudget = 2 * time.Second
+	servers := []ServerConfig{parent, child}
+	if waves := m.budgetWaves(servers); waves != 2 {
+		t.Errorf("budgetWaves = %d, want 2", waves)
+	}
+
+	results := m.runRound(servers)
+	status := make(map[string]string)
+	for _, result := range results {
+		status[result.Server.Name] = result.Status
+	}
+	if status["db"] != "UP" || status["api"] != "DOWN" {
+		t.Fatalf("statuses %v, want db UP and api DOWN", status)
+	}
+	if budget := m.roundBudget(results); budget.ElapsedMs > budget.BudgetMs {
+		t.Errorf("round took %dms of a %dms budget", budget.ElapsedMs, budget.BudgetMs)
+	}
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http/httptrace"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Timing breaks a check's response time down by phase. It is recorded for
+// HTTP checks in -cold mode, where every phase is measured from scratch, and
+// for TCP checks of a host name, which have no TLS or TTFB phase.
+type Timing struct {
+	DNSMs     int64 `json:"dns_ms"`
+	ConnectMs int64 `json:"connect_ms"`
+	TLSMs     int64 `json:"tls_ms,omitempty"`
+	TTFBMs    int64 `json:"ttfb_ms,omitempty"`
+}
+
+func (t *Timing) String() string {
+	parts := []string{
+		fmt.Sprintf("dns %dms", t.DNSMs),
+		fmt.Sprintf("connect %dms", t.ConnectMs),
+	}
+	if t.TLSMs > 0 {
+		parts = append(parts, fmt.Sprintf("tls %dms", t.TLSMs))
+	}
+	if t.TTFBMs > 0 {
+		parts = append(parts, fmt.Sprintf("ttfb %dms", t.TTFBMs))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// timingTrace fills in t as the request progresses.
+func timingTrace(t *Timing) *httptrace.ClientTrace {
+	var dnsStart, connectStart, tlsStart, requestStart time.Time
+	return &httptrace.ClientTrace{
+		GetConn: func(string) {
+			requestStart = time.Now()
+		},
+		DNSStart: func(httptrace.DNSStartInfo) {
+			dnsStart = time.Now()
+		},
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			t.DNSMs = time.Since(dnsStart).Milliseconds()
+		},
+		ConnectStart: func(network, addr string) {
+			connectStart = time.Now()
+		},
+		ConnectDone: func(network, addr string, err error) {
+			t.ConnectMs = time.Since(connectStart).Milliseconds()
+		},
+		TLSHandshakeStart: func() {
+			tlsStart = time.Now()
+		},
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			t.TLSMs = time.Since(tlsStart).Milliseconds()
+		},
+		GotFirstResponseByte: func() {
+			t.TTFBMs = time.Since(requestStart).Milliseconds()
+		},
+	}
+}
+
+// dialTrace records a TCP dial's lookup and connect in t. net.Dialer
+// reports both to the trace in its context, so the dial can still be given
+// the host name and keep the dialer's own handling of it: falling back
+// between address families and splitting the deadline across addresses.
+// The connect is timed from the first attempt to the one that succeeded;
+// with a fallback, attempts run concurrently.
+func dialTrace(t *Timing) *httptrace.ClientTrace {
+	var mu sync.Mutex
+	var dnsStart, connectStart time.Time
+	return &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) {
+			dnsStart = time.Now()
+		},
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			t.DNSMs = time.Since(dnsStart).Milliseconds()
+		},
+		ConnectStart: func(network, addr string) {
+			mu.Lock()
+			defer mu.Unlock()
+			if connectStart.IsZero() {
+				connectStart = time.Now()
+			}
+		},
+		ConnectDone: func(network, addr string, err error) {
+			mu.Lock()
+			defer mu.Unlock()
+			if err == nil {
+				t.ConnectMs = time.Since(connectStart).Milliseconds()
+			}
+		},
+	}
+}
@@ -0,0 +1,52 @@
+package main
+
+import "os"
+
+const (
+	colorReset  = "\033[0m"
+	colorRed    = "\033[31m"
+	colorGreen  = "\033[32m"
+	colorYellow = "\033[33m"
+	colorBlue   = "\033[34m"
+)
+
+var statusColors = map[string]string{
+	"UP":       colorGreen,
+	"DOWN":     colorRed,
+	"DEGRADED": colorYellow,
+	"PAUSED":   colorBlue,
+}
+
+// stdoutIsTerminal reports whether stdout is a character device, i.e. not
+// redirected to a file or pipe.
+func stdoutIsTerminal() bool {
+	info, err := os.Stdout.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// colorEnabled resolves the -color/-no-color choice ("always", "never" or
+// "auto"). Auto honours NO_COLOR and only colors interactive terminals.
+func colorEnabled(mode string) bool {
+	switch mode {
+	case "always":
+		return true
+	case "never":
+		return false
+	}
+	if _, ok := os.LookupEnv("NO_COLOR"); ok {
+		return false
+	}
+	return stdoutIsTerminal()
+}
+
+// colorize wraps text in the color for status when color output is on.
+func (m *Monitor) colorize(status, text string) string {
+	color, ok := statusColors[status]
+	if !m.useColor || !ok {
+		return text
+	}
+	return color + text + colorReset
+}
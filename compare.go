@@ -0,0 +1,113 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// DifferFrom names a second endpoint, typically the stable deployment of
+// a canary, whose response the checked server's must differ from: the
+// value of Header, of the dotted JSON path JSON, or else the whole body.
+type DifferFrom struct {
+	URL    string `json:"url"`
+	Header string `json:"header,omitempty"`
+	JSON   string `json:"json,omitempty"`
+}
+
+// Comparison records the values compared for a server with DifferFrom.
+type Comparison struct {
+	Value string `json:"value"` // from the checked server
+	Other string `json:"other"` // from DifferFrom.URL
+}
+
+// validateDifferFrom checks a server's differ_from at load time.
+func validateDifferFrom(server ServerConfig) error {
+	differ := server.DifferFrom
+	if server.Protocol != "http" && server.Protocol != "https" {
+		return fmt.Errorf("differ_from: only supported for http and https checks")
+	}
+	u, err := url.Parse(differ.URL)
+	if err != nil {
+		return fmt.Errorf("differ_from: %v", err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("differ_from: %q is not an http(s) URL", differ.URL)
+	}
+	if differ.Header != "" && differ.JSON != "" {
+		return fmt.Errorf("differ_from: set header or json, not both")
+	}
+	return nil
+}
+
+// comparedValue extracts the value DifferFrom compares from a response.
+func comparedValue(differ *DifferFrom, header http.Header, body []byte) (string, error) {
+	switch {
+	case differ.Header != "":
+		values, ok := header[http.CanonicalHeaderKey(differ.Header)]
+		if !ok {
+			return "", fmt.Errorf("%s: header missing", differ.Header)
+		}
+		return strings.Join(values, ", "), nil
+	case differ.JSON != "":
+		var doc interface{}
+		if err := json.Unmarshal(body, &doc); err != nil {
+			return "", fmt.Errorf("body is not JSON: %v", err)
+		}
+		value, ok := lookupJSON(doc, differ.JSON)
+		if !ok {
+			return "", fmt.Errorf("%s: field not found", differ.JSON)
+		}
+		return jsonText(value), nil
+	default:
+		return string(bytes.TrimSpace(body)), nil
+	}
+}
+
+// compareWith requests DifferFrom.URL with the check's headers and
+// credentials and returns the value to compare. It gets a client of its
+// own: the checked server's may pin its IP, SNI or Host, or trace timing
+// for -cold, none of which apply to the other endpoint.
+func (m *Monitor) compareWith(ctx context.Context, server ServerConfig, header http.Header) (string, error) {
+	differ := server.DifferFrom
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, differ.URL, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header = header.Clone()
+
+	client := &http.Client{
+		Timeout: time.Duration(server.Timeout) * time.Second,
+		Transport: &http.Transport{
+			DialContext:       m.dialContext,
+			DisableKeepAlives: true,
+		},
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 400 {
+		return "", fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	read, err := readBody(resp, m.maxBodyBytes)
+	if err != nil {
+		return "", err
+	}
+	return comparedValue(differ, resp.Header, read.data)
+}
+
+// shortValue shortens a compared value, e.g. a whole body, for the result.
+func shortValue(value string) string {
+	short := snippet([]byte(value))
+	if len(short) < len(value) {
+		short += "..."
+	}
+	return short
+}
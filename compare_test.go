@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+)
+
+// serveBuild serves responses with the given X-Build header on listener.
+func serveBuild(t *testing.T, listener net.Listener, build string) {
+	t.Helper()
+	server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Build", build)
+		fmt.Fprintln(w, "ok")
+	})}
+	go server.Serve(listener)
+	t.Cleanup(func() { server.Close() })
+}
+
+// TestDifferFromWithPinnedIP checks a canary whose IP is pinned, as with
+// -resolve: the differ_from URL must be fetched from its own host, not
+// from the canary's pinned address on the same port.
+func TestDifferFromWithPinnedIP(t *testing.T) {
+	stable, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := stable.Addr().(*net.TCPAddr).Port
+	canary, err := net.Listen("tcp", fmt.Sprintf("127.0.0.2:%d", port))
+	if err != nil {
+		stable.Close()
+		t.Skipf("no second loopback address: %v", err)
+	}
+	serveBuild(t, stable, "v1")
+	serveBuild(t, canary, "v2")
+
+	m := NewMonitor()
+	m.out = io.Discard
+	server := ServerConfig{
+		Name:       "canary",
+		Host:       "canary.example.test",
+		Port:       port,
+		Protocol:   "http",
+		Timeout:    2,
+		DifferFrom: &DifferFrom{URL: fmt.Sprintf("http://127.0.0.1:%d/", port), Header: "X-Build"},
+		dialIP:     "127.0.0.2",
+	}
+	result := m.checkHTTP(context.Background(), server)
+	if result.Status != "UP" {
+		t.Fatalf("status %s (%s), want UP", result.Status, result.Error)
+	}
+	if result.Compared == nil || result.Compared.Value != "v2" || result.Compared.Other != "v1" {
+		t.Errorf("compared %+v, want v2 against v1", result.Compared)
+	}
+}
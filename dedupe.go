@@ -0,0 +1,46 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// targetKey identifies what a check actually probes: everything in the
+// config except the name and settings that only affect how results are
+// scheduled or alerted on. Servers with equal keys get identical results.
+func targetKey(server ServerConfig) string {
+	server.Name = ""
+	server.Pool = ""
+	server.RiseThreshold = 0
+	server.FallThreshold = 0
+	server.LatencyP95Ms = 0
+	data, _ := json.Marshal(server)
+	return string(data)
+}
+
+// dedupeServers returns the first server of each distinct target, and for
+// each of those the later duplicates that should share its result.
+func dedupeServers(servers []ServerConfig) ([]ServerConfig, map[string][]ServerConfig) {
+	var unique []ServerConfig
+	aliases := make(map[string][]ServerConfig)
+	first := make(map[string]string)
+	for _, server := range servers {
+		key := targetKey(server)
+		if name, ok := first[key]; ok {
+			aliases[name] = append(aliases[name], server)
+			continue
+		}
+		first[key] = server.Name
+		unique = append(unique, server)
+	}
+	return unique, aliases
+}
+
+// aliasResult copies a result checked for server over to its duplicate,
+// keeping any per-target suffix the result's name was given (see -resolve).
+func aliasResult(result HealthResult, server, alias ServerConfig) HealthResult {
+	suffix := strings.TrimPrefix(result.Server.Name, server.Name)
+	result.Server = alias
+	result.Server.Name += suffix
+	return result
+}
@@ -0,0 +1,9 @@
+package main
+
+import _ "embed"
+
+// defaultConfig is used when no config file exists, and written out by
+// -sample.
+//
+//go:embed default_servers.json
+var defaultConfig []byte
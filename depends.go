@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// checkDependencies verifies that every DependsOn names a configured server
+// and that no server depends on itself, directly or through others.
+func checkDependencies(servers []ServerConfig) error {
+	parents := make(map[string]string, len(servers))
+	for _, server := range servers {
+		parents[server.Name] = server.DependsOn
+	}
+
+	for _, server := range servers {
+		if server.DependsOn == "" {
+			continue
+		}
+		if _, ok := parents[server.DependsOn]; !ok {
+			return fmt.Errorf("server %q depends on unknown server %q", server.Name, server.DependsOn)
+		}
+
+		chain := []string{server.Name}
+		for parent := server.DependsOn; parent != ""; parent = parents[parent] {
+			chain = append(chain, parent)
+			if parent == server.Name {
+				return fmt.Errorf("dependency cycle: %s", strings.Join(chain, " -> "))
+			}
+			if len(chain) > len(servers) {
+				// A cycle further up the chain; reported for its own members
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// roundDeps lets dependent checks wait for their parent's result within a
+// round. Parents outside the round (e.g. filtered by tag or disabled) never
+// hold up their dependents.
+type roundDeps struct {
+	mu   sync.Mutex
+	done map[string]chan struct{}
+	up   map[string]bool
+}
+
+func newRoundDeps(servers []ServerConfig, aliases map[string][]ServerConfig) *roundDeps {
+	deps := &roundDeps{
+		done: make(map[string]chan struct{}),
+		up:   make(map[string]bool),
+	}
+	for _, server := range servers {
+		deps.done[server.Name] = make(chan struct{})
+		for _, alias := range aliases[server.Name] {
+			deps.done[alias.Name] = make(chan struct{})
+		}
+	}
+	return deps
+}
+
+// finish records whether the named server was UP (with -resolve: UP for at
+// least one address family) and releases its dependents.
+func (d *roundDeps) finish(name string, up bool) {
+	d.mu.Lock()
+	d.up[name] = up
+	d.mu.Unlock()
+	close(d.done[name])
+}
+
+// skipReason waits for the server's parent and returns why the server
+// should be skipped, or "" to check it.
+func (d *roundDeps) skipReason(ctx context.Context, server ServerConfig) string {
+	done, ok := d.done[server.DependsOn]
+	if server.DependsOn == "" || !ok {
+		return ""
+	}
+	select {
+	case <-done:
+	case <-ctx.Done():
+		return ""
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.up[server.DependsOn] {
+		return ""
+	}
+	return fmt.Sprintf("skipped: depends on %s, which is not UP", server.DependsOn)
+}
+
+func skippedResult(server ServerConfig, reason string) HealthResult {
+	return HealthResult{
+		Server:    server,
+		Status:    "SKIPPED",
+		Timestamp: time.Now(),
+		Error:     reason,
+	}
+}
@@ -0,0 +1,53 @@
+package main
+
+import "fmt"
+
+const (
+	// dnsOutageFraction is the share of a round's checks that must fail to
+	// resolve before the monitor's own resolver is blamed rather than the
+	// servers
+	dnsOutageFraction = 0.5
+	// dnsOutageMinFailures keeps one or two unlucky lookups in a small
+	// config from counting as a resolver outage
+	dnsOutageMinFailures = 3
+)
+
+// isDNSFailure reports whether a result failed because a name could not be
+// resolved, as opposed to resolving to the wrong records.
+func isDNSFailure(result HealthResult) bool {
+	if result.Status != "DOWN" {
+		return false
+	}
+	switch result.ErrorCategory {
+	case "dns timeout", "dns not found", "dns error":
+		return true
+	}
+	return false
+}
+
+// dnsOutage reports whether a round looks like a monitor-side DNS failure
+// (e.g. the local resolver is down): at least dnsOutageMinFailures checks,
+// and dnsOutageFraction of those run, failed to resolve.
+func dnsOutage(results []HealthResult) (failures, checked int, outage bool) {
+	for _, result := range results {
+		if result.Status == "PAUSED" || result.Status == "SKIPPED" {
+			continue
+		}
+		checked++
+		if isDNSFailure(result) {
+			failures++
+		}
+	}
+	outage = failures >= dnsOutageMinFailures && float64(failures) >= dnsOutageFraction*float64(checked)
+	return failures, checked, outage
+}
+
+// warnDNSOutage prints the monitor-side DNS failure warning for a round, if
+// it is one.
+func (m *Monitor) warnDNSOutage(results []HealthResult) {
+	if failures, checked, outage := dnsOutage(results); outage {
+		fmt.Fprintf(m.out, "Warning: monitor-side DNS failure: %d of %d checks failed to resolve; "+
+			"check this host's resolver\n",
+			failures, checked)
+	}
+}
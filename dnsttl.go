@@ -0,0 +1,144 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// nameserverAddress adds the default port 53 to a nameserver given as a
+// bare host.
+func nameserverAddress(nameserver string) string {
+	if _, _, err := net.SplitHostPort(nameserver); err != nil {
+		return net.JoinHostPort(nameserver, "53")
+	}
+	return nameserver
+}
+
+// systemNameserver returns the first nameserver in /etc/resolv.conf, for
+// TTL queries of servers without their own Resolver.
+func systemNameserver() (string, error) {
+	file, err := os.Open("/etc/resolv.conf")
+	if err != nil {
+		return "", fmt.Errorf("no nameserver to query for the TTL (set resolver): %v", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) >= 2 && fields[0] == "nameserver" {
+			return fields[1], nil
+		}
+	}
+	return "", fmt.Errorf("no nameserver to query for the TTL (set resolver)")
+}
+
+// lookupTTL asks the server's nameserver for host's A records, or AAAA if
+// it has none, and returns the lowest TTL in the answer, i.e. how long
+// caches may keep it. net.Resolver doesn't expose TTLs, hence the query
+// of our own.
+func lookupTTL(ctx context.Context, server ServerConfig) (uint32, error) {
+	nameserver := server.Resolver
+	if nameserver == "" {
+		var err error
+		if nameserver, err = systemNameserver(); err != nil {
+			return 0, err
+		}
+	}
+	name, err := dnsmessage.NewName(strings.TrimSuffix(server.Host, ".") + ".")
+	if err != nil {
+		return 0, fmt.Errorf("ttl lookup: %v", err)
+	}
+
+	for _, qtype := range []dnsmessage.Type{dnsmessage.TypeA, dnsmessage.TypeAAAA} {
+		ttl, found, err := queryTTL(ctx, nameserverAddress(nameserver), name, qtype)
+		if err != nil || found {
+			return ttl, err
+		}
+	}
+	return 0, fmt.Errorf("ttl lookup: no A or AAAA records for %s", server.Host)
+}
+
+// queryTTL sends one query over UDP and returns the lowest TTL among the
+// answers, including any CNAMEs on the way; found is false for an empty
+// answer.
+func queryTTL(ctx context.Context, nameserver string, name dnsmessage.Name, qtype dnsmessage.Type) (ttl uint32, found bool, err error) {
+	id := uint16(random.Int63n(1 << 16))
+	query := dnsmessage.Message{
+		Header:    dnsmessage.Header{ID: id, RecursionDesired: true},
+		Questions: []dnsmessage.Question{{Name: name, Type: qtype, Class: dnsmessage.ClassINET}},
+	}
+	packet, err := query.Pack()
+	if err != nil {
+		return 0, false, fmt.Errorf("ttl lookup: %v", err)
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "udp", nameserver)
+	if err != nil {
+		return 0, false, err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	} else {
+		conn.SetDeadline(time.Now().Add(5 * time.Second))
+	}
+	if _, err := conn.Write(packet); err != nil {
+		return 0, false, err
+	}
+
+	buf := make([]byte, 4096)
+	for {
+		n, err := conn.Read(buf)
+		if err != nil {
+			return 0, false, err
+		}
+		var parser dnsmessage.Parser
+		header, err := parser.Start(buf[:n])
+		if err != nil || header.ID != id || !header.Response {
+			// Not the reply to our query; keep waiting for it
+			continue
+		}
+		if header.RCode != dnsmessage.RCodeSuccess {
+			return 0, false, fmt.Errorf("ttl lookup: %s", header.RCode)
+		}
+		if err := parser.SkipAllQuestions(); err != nil {
+			return 0, false, fmt.Errorf("ttl lookup: %v", err)
+		}
+		for {
+			answer, err := parser.AnswerHeader()
+			if err == dnsmessage.ErrSectionDone {
+				return ttl, found, nil
+			}
+			if err != nil {
+				return 0, false, fmt.Errorf("ttl lookup: %v", err)
+			}
+			if !found || answer.TTL < ttl {
+				ttl = answer.TTL
+			}
+			found = true
+			if err := parser.SkipAnswer(); err != nil {
+				return 0, false, fmt.Errorf("ttl lookup: %v", err)
+			}
+		}
+	}
+}
+
+// ttlRange renders a server's TTL bounds, e.g. "60s-300s" or ">= 60s".
+func ttlRange(server ServerConfig) string {
+	switch {
+	case server.MinTTL != nil && server.MaxTTL != nil:
+		return fmt.Sprintf("%ds-%ds", *server.MinTTL, *server.MaxTTL)
+	case server.MinTTL != nil:
+		return fmt.Sprintf(">= %ds", *server.MinTTL)
+	}
+	return fmt.Sprintf("<= %ds", *server.MaxTTL)
+}
@@ -0,0 +1,61 @@
+package main
+
+import "sort"
+
+// EffectiveConfig is the configuration a report's round actually ran
+// with: the servers after includes, defaults, environment and secret files
+// were applied (secrets redacted), and the settings that change how they
+// were checked, so a report can be audited or the run reproduced.
+type EffectiveConfig struct {
+	Source       string         `json:"source"` // config file, or where the servers came from
+	Concurrency  int            `json:"concurrency"`
+	Pools        map[string]int `json:"pools,omitempty"` // -pool limits
+	MaxBodyBytes int64          `json:"max_body_bytes"`
+	ProbeCount   int            `json:"probe_count,omitempty"`
+	Cold         bool           `json:"cold,omitempty"`
+	PerFamily    bool           `json:"per_family,omitempty"`
+	AllAddrs     bool           `json:"all_addrs,omitempty"`
+	Dedupe       bool           `json:"dedupe,omitempty"`
+	Seed         int64          `json:"seed"`
+	BudgetMs     int64          `json:"timeout_budget_ms,omitempty"` // -healthcheck-timeout-budget
+	Disabled     int            `json:"disabled,omitempty"`          // servers disabled in the config
+	Exclude      []string       `json:"exclude,omitempty"`
+	ExcludeTags  []string       `json:"exclude_tags,omitempty"`
+	Servers      []ServerConfig `json:"servers"`
+}
+
+// effectiveConfig describes the monitor's current configuration.
+func (m *Monitor) effectiveConfig() *EffectiveConfig {
+	config := &EffectiveConfig{
+		Source:       m.configSource,
+		Concurrency:  m.maxConcurrency,
+		MaxBodyBytes: m.maxBodyBytes,
+		ProbeCount:   m.probes,
+		Cold:         m.cold,
+		PerFamily:    m.perFamily,
+		AllAddrs:     m.allAddrs,
+		Dedupe:       m.dedupe,
+		Seed:         randomSeed,
+		BudgetMs:     m.timeoutBudget.Milliseconds(),
+		Disabled:     m.disabled,
+		Exclude:      sortedKeys(m.excludeNames),
+		ExcludeTags:  sortedKeys(m.excludeTags),
+		Servers:      make([]ServerConfig, len(m.servers)),
+	}
+	if len(m.poolLimits) > 0 {
+		config.Pools = m.poolLimits
+	}
+	for i, server := range m.servers {
+		config.Servers[i] = server.redacted()
+	}
+	return config
+}
+
+func sortedKeys(set map[string]bool) []string {
+	var keys []string
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,43 @@
+//go:build !windows
+
+package main
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// isConnRefused reports whether err is a refused connection: nothing is
+// listening on the port.
+func isConnRefused(err error) bool {
+	return errors.Is(err, syscall.ECONNREFUSED)
+}
+
+// isConnReset reports whether err is a reset or aborted connection:
+// something is listening but dropped the connection, e.g. an overloaded
+// service or a firewall rejecting it.
+func isConnReset(err error) bool {
+	return errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNABORTED)
+}
+
+// isSocketsExhausted covers platforms where running out of sockets has its
+// own error; elsewhere EMFILE already does.
+func isSocketsExhausted(err error) bool {
+	return false
+}
+
+// fdUsage returns how many file descriptors the process has open and its
+// soft limit, where /proc/self/fd or /dev/fd lists them.
+func fdUsage() (open, limit int, ok bool) {
+	var rlimit syscall.Rlimit
+	if err := syscall.Getrlimit(syscall.RLIMIT_NOFILE, &rlimit); err != nil {
+		return 0, 0, false
+	}
+	for _, dir := range []string{"/proc/self/fd", "/dev/fd"} {
+		if entries, err := os.ReadDir(dir); err == nil {
+			return len(entries), int(rlimit.Cur), true
+		}
+	}
+	return 0, 0, false
+}
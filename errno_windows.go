@@ -0,0 +1,35 @@
+package main
+
+import (
+	"errors"
+	"syscall"
+)
+
+// Winsock reports refused connections with its own error code, which
+// syscall.ECONNREFUSED doesn't match.
+const wsaeconnrefused syscall.Errno = 10061
+
+func isConnRefused(err error) bool {
+	return errors.Is(err, wsaeconnrefused) || errors.Is(err, syscall.ECONNREFUSED)
+}
+
+func isConnReset(err error) bool {
+	return errors.Is(err, syscall.WSAECONNRESET) || errors.Is(err, syscall.WSAECONNABORTED) ||
+		errors.Is(err, syscall.ECONNRESET)
+}
+
+// Winsock's own "too many open sockets" and "no buffer space" codes.
+const (
+	wsaemfile  syscall.Errno = 10024
+	wsaenobufs syscall.Errno = 10055
+)
+
+func isSocketsExhausted(err error) bool {
+	return errors.Is(err, wsaemfile) || errors.Is(err, wsaenobufs)
+}
+
+// fdUsage is not available on Windows, which has no descriptor limit to
+// compare against.
+func fdUsage() (open, limit int, ok bool) {
+	return 0, 0, false
+}
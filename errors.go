@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"syscall"
+)
+
+// classifyError names the stage at which a check failed, e.g. "dns timeout"
+// or "connect refused", so a report can be triaged without decoding raw
+// errors like "i/o timeout".
+func classifyError(err error) string {
+	// Checked first: a resolver that can't open a socket still reports a
+	// DNS error
+	if isTooManyFiles(err) {
+		return "fd limit"
+	}
+
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		switch {
+		case dnsErr.IsTimeout:
+			return "dns timeout"
+		case dnsErr.IsNotFound:
+			return "dns not found"
+		}
+		return "dns error"
+	}
+
+	if strings.Contains(err.Error(), "TLS handshake timeout") {
+		return "tls handshake timeout"
+	}
+	var certErr *tls.CertificateVerificationError
+	var unknownAuthority x509.UnknownAuthorityError
+	var hostnameErr x509.HostnameError
+	var invalidCert x509.CertificateInvalidError
+	var recordErr tls.RecordHeaderError
+	if errors.As(err, &certErr) || errors.As(err, &unknownAuthority) ||
+		errors.As(err, &hostnameErr) || errors.As(err, &invalidCert) || errors.As(err, &recordErr) {
+		return "tls error"
+	}
+
+	var kafkaErr *kafkaError
+	if errors.As(err, &kafkaErr) {
+		return "kafka error"
+	}
+
+	var ifaceErr *interfaceError
+	if errors.As(err, &ifaceErr) {
+		return "interface error"
+	}
+
+	if errors.Is(err, errPayloadMismatch) {
+		return "payload mismatch"
+	}
+
+	if errors.Is(err, context.Canceled) {
+		return "canceled"
+	}
+
+	var opErr *net.OpError
+	if errors.As(err, &opErr) {
+		stage := "connect"
+		if opErr.Op != "dial" {
+			stage = opErr.Op
+		}
+		switch {
+		case opErr.Timeout():
+			return stage + " timeout"
+		case isConnRefused(err):
+			return "connect refused"
+		case isConnReset(err):
+			return "connection reset"
+		case errors.Is(err, syscall.EHOSTUNREACH), errors.Is(err, syscall.ENETUNREACH):
+			return "host unreachable"
+		}
+		return stage + " error"
+	}
+
+	// The peer accepted the connection, then closed it without a response
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return "connection closed"
+	}
+
+	// http.Client timeouts that happen after the connection is established
+	var timeout interface{ Timeout() bool }
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &timeout) && timeout.Timeout()) {
+		return "response timeout"
+	}
+	return "error"
+}
+
+// setFailure marks result DOWN with err, prefixed with its category.
+func setFailure(result *HealthResult, err error) {
+	result.Status = "DOWN"
+	result.ErrorCategory = classifyError(err)
+	result.Error = result.ErrorCategory + ": " + err.Error()
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// addExcludes adds a comma-separated -exclude or -exclude-tag value to set.
+func addExcludes(set map[string]bool, value string) map[string]bool {
+	if set == nil {
+		set = make(map[string]bool)
+	}
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			set[item] = true
+		}
+	}
+	return set
+}
+
+// excludeServers drops the servers named by -exclude or carrying a tag
+// given to -exclude-tag, returning the rest and how many were dropped.
+// Names that match no server are warned about, as they are likely typos.
+func (m *Monitor) excludeServers(servers []ServerConfig) ([]ServerConfig, int) {
+	if len(m.excludeNames) == 0 && len(m.excludeTags) == 0 {
+		return servers, 0
+	}
+
+	matched := make(map[string]bool)
+	var kept []ServerConfig
+	for _, server := range servers {
+		if m.excludeNames[server.Name] {
+			matched[server.Name] = true
+			continue
+		}
+		if m.hasExcludedTag(server) {
+			continue
+		}
+		kept = append(kept, server)
+	}
+	for name := range m.excludeNames {
+		if !matched[name] {
+			fmt.Fprintf(m.out, "Warning: -exclude names unknown server %q\n", name)
+		}
+	}
+	return kept, len(servers) - len(kept)
+}
+
+func (m *Monitor) hasExcludedTag(server ServerConfig) bool {
+	for _, tag := range server.Tags {
+		if m.excludeTags[tag] {
+			return true
+		}
+	}
+	return false
+}
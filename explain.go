@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http/httptrace"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// snippetLen is how much of a response body -explain shows.
+const snippetLen = 256
+
+func snippet(body []byte) string {
+	if len(body) > snippetLen {
+		body = body[:snippetLen]
+	}
+	// Don't cut a multi-byte character in half
+	for len(body) > 0 && !utf8.Valid(body) {
+		body = body[:len(body)-1]
+	}
+	return string(body)
+}
+
+// Explain runs the check for a single named server, printing each step
+// (resolution, connect, TLS handshake, response) as it happens, followed by
+// the result as JSON.
+func (m *Monitor) Explain(name string) error {
+	var server *ServerConfig
+	for i := range m.servers {
+		if m.servers[i].Name == name {
+			server = &m.servers[i]
+			break
+		}
+	}
+	if server == nil {
+		return fmt.Errorf("no server named %q in config", name)
+	}
+
+	fmt.Printf("Explaining %q: %s %s:%d (timeout %ds)\n",
+		server.Name, server.Protocol, server.Host, server.Port, server.Timeout)
+
+	ctx := context.Background()
+	if server.Protocol != "dns" && net.ParseIP(server.Host) == nil {
+		explainResolve(ctx, resolverFor(*server), server.Host)
+	}
+	if server.Protocol == "http" || server.Protocol == "https" {
+		ctx = httptrace.WithClientTrace(ctx, explainTrace())
+	}
+
+	result := m.check(ctx, *server)
+
+	if len(result.ResolvedAddrs) > 0 {
+		fmt.Printf("  Records:  %s\n", strings.Join(result.ResolvedAddrs, ", "))
+	}
+	if result.StatusCode != 0 {
+		fmt.Printf("  Status:   HTTP %d (%d bytes)\n", result.StatusCode, result.BytesRead)
+	}
+	if result.bodySnippet != "" {
+		fmt.Printf("  Body:     %q\n", result.bodySnippet)
+	}
+	fmt.Printf("  Result:   %s (%dms)", result.Status, result.ResponseTime)
+	if result.Error != "" {
+		fmt.Printf(" - Error: %s", result.Error)
+	}
+	fmt.Println()
+
+	result.Server = result.Server.redacted()
+	data, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(data))
+	return nil
+}
+
+func explainResolve(ctx context.Context, resolver *net.Resolver, host string) {
+	start := time.Now()
+	addrs, err := resolver.LookupHost(ctx, host)
+	if err != nil {
+		fmt.Printf("  DNS:      %s failed after %dms: %v\n", host, time.Since(start).Milliseconds(), err)
+		return
+	}
+	fmt.Printf("  DNS:      %s -> %s (%dms)\n", host, strings.Join(addrs, ", "), time.Since(start).Milliseconds())
+}
+
+// explainTrace prints connection-level events of an HTTP check.
+func explainTrace() *httptrace.ClientTrace {
+	var connectStart, tlsStart, requestStart time.Time
+	return &httptrace.ClientTrace{
+		GetConn: func(string) {
+			requestStart = time.Now()
+		},
+		ConnectStart: func(network, addr string) {
+			connectStart = time.Now()
+		},
+		ConnectDone: func(network, addr string, err error) {
+			elapsed := time.Since(connectStart).Milliseconds()
+			if err != nil {
+				fmt.Printf("  Connect:  %s failed after %dms: %v\n", addr, elapsed, err)
+				return
+			}
+			fmt.Printf("  Connect:  %s (%dms)\n", addr, elapsed)
+		},
+		TLSHandshakeStart: func() {
+			tlsStart = time.Now()
+		},
+		TLSHandshakeDone: func(state tls.ConnectionState, err error) {
+			elapsed := time.Since(tlsStart).Milliseconds()
+			if err != nil {
+				fmt.Printf("  TLS:      handshake failed after %dms: %v\n", elapsed, err)
+				return
+			}
+			fmt.Printf("  TLS:      %s, %s (%dms)\n",
+				tls.VersionName(state.Version), tls.CipherSuiteName(state.CipherSuite), elapsed)
+			if len(state.PeerCertificates) > 0 {
+				cert := state.PeerCertificates[0]
+				fmt.Printf("  Cert:     subject=%q issuer=%q expires=%s\n",
+					cert.Subject.CommonName, cert.Issuer.CommonName, cert.NotAfter.Format(time.RFC3339))
+			}
+		},
+		GotFirstResponseByte: func() {
+			fmt.Printf("  TTFB:     %dms\n", time.Since(requestStart).Milliseconds())
+		},
+	}
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"syscall"
+)
+
+// isTooManyFiles reports whether err means the monitor ran out of file
+// descriptors: every socket it opens from then on fails too, so the error
+// says nothing about the server being checked.
+func isTooManyFiles(err error) bool {
+	return errors.Is(err, syscall.EMFILE) || errors.Is(err, syscall.ENFILE) || isSocketsExhausted(err) ||
+		strings.Contains(err.Error(), "too many open files")
+}
+
+// countFDLimit counts a round's checks that failed on the monitor's own
+// file descriptor limit.
+func countFDLimit(results []HealthResult) int {
+	n := 0
+	for _, result := range results {
+		if result.Status == "DOWN" && result.ErrorCategory == "fd limit" {
+			n++
+		}
+	}
+	return n
+}
+
+// warnFDLimit prints one warning for a round whose checks hit the file
+// descriptor limit, instead of leaving operators to make sense of each
+// server's error, with the current usage where the platform reports it.
+func (m *Monitor) warnFDLimit(results []HealthResult) {
+	failed := countFDLimit(results)
+	if failed == 0 {
+		return
+	}
+	usage := ""
+	if open, limit, ok := fdUsage(); ok {
+		usage = fmt.Sprintf(" (%d of %d in use)", open, limit)
+	}
+	fmt.Fprintf(m.out, "Warning: file descriptor limit reached%s: %d checks failed with \"too many open files\"; "+
+		"reduce -concurrency (now %d) or raise ulimit -n\n",
+		usage, failed, m.maxConcurrency)
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// downThreshold is the -threshold gate for -once/-report runs: the run fails
+// only when more than Count servers, or more than Percent of them, are DOWN.
+type downThreshold struct {
+	Count     int
+	Percent   float64
+	IsPercent bool
+}
+
+// parseThreshold accepts an absolute count ("3") or a percentage ("10%").
+func parseThreshold(s string) (downThreshold, error) {
+	if strings.HasSuffix(s, "%") {
+		percent, err := strconv.ParseFloat(strings.TrimSuffix(s, "%"), 64)
+		if err != nil || percent < 0 || percent > 100 {
+			return downThreshold{}, fmt.Errorf("invalid threshold %q: expected a percentage between 0%% and 100%%", s)
+		}
+		return downThreshold{Percent: percent, IsPercent: true}, nil
+	}
+
+	count, err := strconv.Atoi(s)
+	if err != nil || count < 0 {
+		return downThreshold{}, fmt.Errorf("invalid threshold %q: expected a count or a percentage", s)
+	}
+	return downThreshold{Count: count}, nil
+}
+
+func (t downThreshold) String() string {
+	if t.IsPercent {
+		return strconv.FormatFloat(t.Percent, 'f', -1, 64) + "%"
+	}
+	return strconv.Itoa(t.Count)
+}
+
+func (t downThreshold) exceeded(down, total int) bool {
+	if t.IsPercent {
+		return downPercent(down, total) > t.Percent
+	}
+	return down > t.Count
+}
+
+func downPercent(down, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(down) * 100 / float64(total)
+}
+
+func countDown(results []HealthResult) int {
+	down := 0
+	for _, result := range results {
+		if result.Status == "DOWN" {
+			down++
+		}
+	}
+	return down
+}
+
+// onelineSummary renders a round as a single status-bar friendly line such
+// as "UP:47 DOWN:3 DEGRADED:1", optionally followed by the DOWN servers.
+func onelineSummary(results []HealthResult, withNames bool) string {
+	counts := make(map[string]int)
+	var downNames []string
+	for _, result := range results {
+		counts[result.Status]++
+		if result.Status == "DOWN" {
+			downNames = append(downNames, result.Server.Name)
+		}
+	}
+
+	line := fmt.Sprintf("UP:%d DOWN:%d", counts["UP"], counts["DOWN"])
+	for _, status := range []string{"DEGRADED", "PAUSED", "SKIPPED"} {
+		if counts[status] > 0 {
+			line += fmt.Sprintf(" %s:%d", status, counts[status])
+		}
+	}
+	if withNames && len(downNames) > 0 {
+		sort.Strings(downNames)
+		line += " (" + strings.Join(downNames, ", ") + ")"
+	}
+	return line
+}
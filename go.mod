@@ -1,3 +1,5 @@
 module server-health-monitor
 
 go 1.22.2
+
+require golang.org/x/net v0.33.0
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+)
+
+// loadExpectBodyFile reads a server's ExpectBodyFile once, at load time, so
+// checks never touch the filesystem.
+func loadExpectBodyFile(server *ServerConfig) error {
+	data, err := os.ReadFile(server.ExpectBodyFile)
+	if err != nil {
+		return fmt.Errorf("failed to read expect_body_file: %v", err)
+	}
+	server.expectBody = bytes.TrimSpace(data)
+	return nil
+}
+
+// diffBody compares a response body with the expected content, ignoring
+// leading and trailing whitespace (such as the newline most editors end a
+// file with). It returns nil on a match, or an error naming the first line
+// that differs.
+func diffBody(want, got []byte) error {
+	got = bytes.TrimSpace(got)
+	if bytes.Equal(want, got) {
+		return nil
+	}
+
+	wantLines := bytes.Split(want, []byte("\n"))
+	gotLines := bytes.Split(got, []byte("\n"))
+	for i := 0; i < len(wantLines) && i < len(gotLines); i++ {
+		if col := firstDifference(wantLines[i], gotLines[i]); col >= 0 {
+			return fmt.Errorf("line %d, column %d: want %q, got %q",
+				i+1, col+1, excerpt(wantLines[i], col), excerpt(gotLines[i], col))
+		}
+	}
+	if len(gotLines) < len(wantLines) {
+		return fmt.Errorf("body ends after line %d of %d; line %d should be %q",
+			len(gotLines), len(wantLines), len(gotLines)+1, excerpt(wantLines[len(gotLines)], 0))
+	}
+	return fmt.Errorf("body has %d lines, want %d; unexpected line %d: %q",
+		len(gotLines), len(wantLines), len(wantLines)+1, excerpt(gotLines[len(wantLines)], 0))
+}
+
+// firstDifference returns the index of the first byte where a and b
+// differ, or -1 if they are equal.
+func firstDifference(a, b []byte) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] != b[i] {
+			return i
+		}
+	}
+	if len(a) == len(b) {
+		return -1
+	}
+	return min(len(a), len(b))
+}
+
+// diffContext is how many bytes of a line a body diff shows around the
+// first difference
+const diffContext = 40
+
+// excerpt returns the part of line around col, so a difference deep into a
+// long line is still visible.
+func excerpt(line []byte, col int) string {
+	start := max(0, col-diffContext/4)
+	end := min(len(line), start+diffContext)
+	return snippet(line[start:end])
+}
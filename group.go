@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+)
+
+// groupKeys are the fields accepted by -group-by.
+var groupKeys = []string{"group", "tag", "protocol", "pool"}
+
+// ungrouped is the group key for servers without a value for the field.
+const ungrouped = "(none)"
+
+// ReportGroup is one -group-by group of a report, with its own counts.
+type ReportGroup struct {
+	Summary struct {
+		Total    int `json:"total"`
+		Up       int `json:"up"`
+		Down     int `json:"down"`
+		Degraded int `json:"degraded,omitempty"`
+		Paused   int `json:"paused,omitempty"`
+	} `json:"summary"`
+	Results []HealthResult `json:"results"`
+}
+
+func parseGroupBy(key string) (string, error) {
+	if !slices.Contains(groupKeys, key) {
+		return "", fmt.Errorf("invalid -group-by %q: want one of %v", key, groupKeys)
+	}
+	return key, nil
+}
+
+// resultGroups returns the groups a result belongs to. With "tag" a server
+// is listed under each of its tags.
+func resultGroups(result HealthResult, by string) []string {
+	var keys []string
+	switch by {
+	case "group":
+		keys = []string{result.Server.Group}
+	case "protocol":
+		keys = []string{result.Server.Protocol}
+	case "pool":
+		keys = []string{result.Server.Pool}
+	case "tag":
+		keys = result.Server.Tags
+	}
+	if len(keys) == 0 || (len(keys) == 1 && keys[0] == "") {
+		return []string{ungrouped}
+	}
+	return keys
+}
+
+// groupResults nests results under their -group-by keys.
+func groupResults(results []HealthResult, by string) map[string]*ReportGroup {
+	groups := make(map[string]*ReportGroup)
+	for _, result := range results {
+		for _, key := range resultGroups(result, by) {
+			group, ok := groups[key]
+			if !ok {
+				group = &ReportGroup{}
+				groups[key] = group
+			}
+			group.Results = append(group.Results, result)
+			group.Summary.Total++
+			switch result.Status {
+			case "UP":
+				group.Summary.Up++
+			case "DEGRADED":
+				group.Summary.Degraded++
+			case "PAUSED":
+				group.Summary.Paused++
+			case "SKIPPED":
+			default:
+				group.Summary.Down++
+			}
+		}
+	}
+	return groups
+}
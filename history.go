@@ -0,0 +1,61 @@
+package main
+
+import "time"
+
+// alertHistorySize bounds how many state changes /alerts remembers.
+const alertHistorySize = 256
+
+// AlertEvent is a confirmed state change as reported by /alerts.
+type AlertEvent struct {
+	Server    string    `json:"server"`
+	From      string    `json:"from"`
+	To        string    `json:"to"`
+	Timestamp time.Time `json:"timestamp"`
+	Resolved  bool      `json:"resolved"` // the server has been UP since
+}
+
+// alertHistory is a fixed-size ring buffer of recent state changes.
+type alertHistory struct {
+	events [alertHistorySize]AlertEvent
+	next   int
+	count  int
+}
+
+// add records a state change. A change to UP resolves the server's earlier
+// events, and is itself resolved.
+func (h *alertHistory) add(event AlertEvent) {
+	if event.To == "UP" {
+		event.Resolved = true
+		for i := 0; i < h.count; i++ {
+			if h.events[i].Server == event.Server {
+				h.events[i].Resolved = true
+			}
+		}
+	}
+
+	h.events[h.next] = event
+	h.next = (h.next + 1) % alertHistorySize
+	if h.count < alertHistorySize {
+		h.count++
+	}
+}
+
+// recent returns up to n of the latest events, newest first.
+func (h *alertHistory) recent(n int) []AlertEvent {
+	if n <= 0 || n > h.count {
+		n = h.count
+	}
+	events := make([]AlertEvent, n)
+	for i := 0; i < n; i++ {
+		events[i] = h.events[(h.next-1-i+alertHistorySize)%alertHistorySize]
+	}
+	return events
+}
+
+// Alerts returns up to n of the most recent state changes, newest first;
+// n <= 0 returns everything still in the buffer.
+func (m *Monitor) Alerts(n int) []AlertEvent {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.history.recent(n)
+}
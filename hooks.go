@@ -0,0 +1,59 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"time"
+)
+
+// defaultHookTimeout bounds how long a -pre-hook or -post-hook may run.
+const defaultHookTimeout = 30 * time.Second
+
+// runHook runs command through the shell with the given extra environment
+// and stdin. Failures are printed but never stop monitoring.
+func (m *Monitor) runHook(name, command string, env []string, stdin []byte) {
+	ctx, cancel := context.WithTimeout(context.Background(), m.hookTimeout)
+	defer cancel()
+
+	shell, flag := "sh", "-c"
+	if runtime.GOOS == "windows" {
+		shell, flag = "cmd", "/C"
+	}
+	cmd := exec.CommandContext(ctx, shell, flag, command)
+	cmd.Env = append(os.Environ(), env...)
+	cmd.Stdin = bytes.NewReader(stdin)
+	cmd.Stdout = m.out
+	cmd.Stderr = m.out
+	// Don't wait on children that outlive a killed shell
+	cmd.WaitDelay = time.Second
+
+	err := cmd.Run()
+	if ctx.Err() == context.DeadlineExceeded {
+		fmt.Fprintf(m.out, "Warning: %s timed out after %v\n", name, m.hookTimeout)
+	} else if err != nil {
+		fmt.Fprintf(m.out, "Warning: %s failed: %v\n", name, err)
+	}
+}
+
+// runPostHook passes a round's summary to the -post-hook as HM_TOTAL,
+// HM_UP and HM_DOWN, and its full report as JSON on stdin.
+func (m *Monitor) runPostHook(results []HealthResult) {
+	report := m.newReport(results)
+	data, err := json.Marshal(report)
+	if err != nil {
+		fmt.Fprintf(m.out, "Warning: post-hook: %v\n", err)
+		return
+	}
+	env := []string{
+		"HM_TOTAL=" + strconv.Itoa(report.Summary.Total),
+		"HM_UP=" + strconv.Itoa(report.Summary.Up),
+		"HM_DOWN=" + strconv.Itoa(report.Summary.Down),
+	}
+	m.runHook("post-hook", m.postHook, env, data)
+}
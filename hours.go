@@ -0,0 +1,149 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// activeWindow is one time range of a schedule, on the days it applies to.
+// A range that ends before it starts runs past midnight into the next day.
+type activeWindow struct {
+	days       [7]bool
+	start, end int // minutes since midnight; end may be 24*60
+}
+
+// activeHours is a parsed Schedule: the server is checked while any of its
+// windows is open.
+type activeHours struct {
+	windows  []activeWindow
+	location *time.Location // nil for the monitor's -timezone
+}
+
+// parseSchedule parses windows such as "Mon-Fri 08:00-18:00" separated by
+// ";", each with optional days (a range like "Mon-Fri" or a list like
+// "Sat,Sun"; every day when omitted) and optionally ending with a time zone,
+// e.g. "Mon-Fri 08:00-18:00 Europe/Berlin; Sat 10:00-14:00".
+func parseSchedule(spec string) (*activeHours, error) {
+	hours := &activeHours{}
+	for _, part := range strings.Split(spec, ";") {
+		fields := strings.Fields(part)
+		if len(fields) == 0 {
+			continue
+		}
+
+		window := activeWindow{}
+		for i := range window.days {
+			window.days[i] = true
+		}
+		if _, _, ok := strings.Cut(fields[0], ":"); !ok {
+			days, err := parseDays(fields[0])
+			if err != nil {
+				return nil, err
+			}
+			window.days = days
+			fields = fields[1:]
+		}
+		if len(fields) == 0 || len(fields) > 2 {
+			return nil, fmt.Errorf("invalid schedule window %q: want [days] HH:MM-HH:MM [time zone]", strings.TrimSpace(part))
+		}
+
+		from, to, ok := strings.Cut(fields[0], "-")
+		if !ok {
+			return nil, fmt.Errorf("invalid time range %q: want HH:MM-HH:MM", fields[0])
+		}
+		var err error
+		if window.start, err = parseClock(from); err != nil {
+			return nil, err
+		}
+		if window.end, err = parseClock(to); err != nil {
+			return nil, err
+		}
+		if window.start == window.end {
+			return nil, fmt.Errorf("empty time range %q", fields[0])
+		}
+
+		if len(fields) == 2 {
+			location, err := time.LoadLocation(fields[1])
+			if err != nil {
+				return nil, fmt.Errorf("invalid time zone %q: %v", fields[1], err)
+			}
+			if hours.location != nil && hours.location.String() != location.String() {
+				return nil, fmt.Errorf("schedule mixes time zones %s and %s", hours.location, location)
+			}
+			hours.location = location
+		}
+		hours.windows = append(hours.windows, window)
+	}
+	if len(hours.windows) == 0 {
+		return nil, fmt.Errorf("empty schedule")
+	}
+	return hours, nil
+}
+
+func parseDays(spec string) ([7]bool, error) {
+	var days [7]bool
+	for _, item := range strings.Split(spec, ",") {
+		first, last, isRange := strings.Cut(item, "-")
+		from, fromOK := weekdays[strings.ToLower(first)]
+		to, toOK := from, fromOK
+		if isRange {
+			to, toOK = weekdays[strings.ToLower(last)]
+		}
+		if !fromOK || !toOK {
+			return days, fmt.Errorf("invalid days %q: use Mon, Tue, ... Sun, ranges like Mon-Fri or lists like Sat,Sun", spec)
+		}
+		// Ranges may wrap around the week, e.g. Fri-Mon
+		for day := from; ; day = (day + 1) % 7 {
+			days[day] = true
+			if day == to {
+				break
+			}
+		}
+	}
+	return days, nil
+}
+
+// parseClock parses "HH:MM" into minutes since midnight; "24:00" is the end
+// of the day.
+func parseClock(s string) (int, error) {
+	hh, mm, ok := strings.Cut(s, ":")
+	h, errH := strconv.Atoi(hh)
+	m, errM := strconv.Atoi(mm)
+	if !ok || errH != nil || errM != nil || h < 0 || m < 0 || m > 59 || h > 24 || h == 24 && m != 0 {
+		return 0, fmt.Errorf("invalid time %q: want HH:MM", s)
+	}
+	return h*60 + m, nil
+}
+
+// active reports whether t falls in one of the windows, in the schedule's
+// time zone or else in location.
+func (h *activeHours) active(t time.Time, location *time.Location) bool {
+	if h.location != nil {
+		location = h.location
+	}
+	t = t.In(location)
+	day := t.Weekday()
+	yesterday := (day + 6) % 7
+	minute := t.Hour()*60 + t.Minute()
+	for _, w := range h.windows {
+		if w.start < w.end {
+			if w.days[day] && minute >= w.start && minute < w.end {
+				return true
+			}
+			continue
+		}
+		// Past midnight: the evening part today, the morning part of a
+		// window that started yesterday
+		if w.days[day] && minute >= w.start || w.days[yesterday] && minute < w.end {
+			return true
+		}
+	}
+	return false
+}
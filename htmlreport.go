@@ -0,0 +1,79 @@
+package main
+
+import (
+	"bytes"
+	"html/template"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// reportFormat infers a -report file's format from its extension: "html"
+// for .html/.htm, otherwise "json".
+func reportFormat(filename string) string {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".html", ".htm":
+		return "html"
+	}
+	return "json"
+}
+
+// htmlSection is a table of the HTML report: all results, or one -group-by
+// group.
+type htmlSection struct {
+	Name    string
+	Results []HealthResult
+}
+
+var htmlReportTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Server Health Report</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; margin-bottom: 2em; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: left; }
+td.time { text-align: right; }
+tr.UP td.status { color: #080; }
+tr.DEGRADED td.status { color: #b80; }
+tr.DOWN td.status { color: #c00; font-weight: bold; }
+tr.SKIPPED td.status, tr.PAUSED td.status { color: #888; }
+</style>
+</head>
+<body>
+<h1>Server Health Report</h1>
+<p>{{.Timestamp.Format "2006-01-02 15:04:05 MST"}}: {{.Summary.Total}} servers,
+{{.Summary.Up}} UP, {{.Summary.Down}} DOWN{{if .Summary.Degraded}}, {{.Summary.Degraded}} DEGRADED{{end}}{{if .Summary.Skipped}}, {{.Summary.Skipped}} SKIPPED{{end}}{{if .Summary.Paused}}, {{.Summary.Paused}} PAUSED{{end}}{{if .Summary.Omitted}} ({{.Summary.Omitted}} not listed){{end}}</p>
+{{range .Sections}}{{if .Name}}<h2>{{.Name}}</h2>
+{{end}}<table>
+<tr><th>Status</th><th>Server</th><th>Target</th><th>Protocol</th><th>Response</th><th>Error</th></tr>
+{{range .Results}}<tr class="{{.Status}}"><td class="status">{{.Status}}</td><td>{{.Server.Name}}</td><td>{{.Server.Host}}:{{.Server.Port}}</td><td>{{.Server.Protocol}}</td><td class="time">{{.ResponseTime}}ms</td><td>{{.Error}}</td></tr>
+{{end}}</table>
+{{end}}</body>
+</html>
+`))
+
+// renderHTMLReport renders a report as a standalone HTML page for people,
+// one table per -group-by group.
+func renderHTMLReport(report Report) ([]byte, error) {
+	sections := []htmlSection{{Results: report.Results}}
+	if report.Groups != nil {
+		names := make([]string, 0, len(report.Groups))
+		for name := range report.Groups {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		sections = sections[:0]
+		for _, name := range names {
+			sections = append(sections, htmlSection{Name: name, Results: report.Groups[name].Results})
+		}
+	}
+
+	var b bytes.Buffer
+	err := htmlReportTemplate.Execute(&b, struct {
+		Report
+		Sections []htmlSection
+	}{report, sections})
+	return b.Bytes(), err
+}
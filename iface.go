@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"net"
+)
+
+// interfaceError reports an Interface that can't be dialed from.
+type interfaceError struct {
+	name   string
+	reason string
+}
+
+func (e *interfaceError) Error() string {
+	return "interface " + e.name + ": " + e.reason
+}
+
+// interfaceAddr picks the address of the named network interface to bind
+// to when connecting to host: an IPv6 one for IPv6 literals, otherwise IPv4
+// if the interface has one. Link-local addresses are never used.
+func interfaceAddr(name, host string) (net.IP, error) {
+	iface, err := net.InterfaceByName(name)
+	if err != nil {
+		return nil, &interfaceError{name, "no such network interface"}
+	}
+	addrs, err := iface.Addrs()
+	if err != nil {
+		return nil, &interfaceError{name, err.Error()}
+	}
+
+	var v4, v6 net.IP
+	for _, addr := range addrs {
+		ipNet, ok := addr.(*net.IPNet)
+		if !ok || ipNet.IP.IsLinkLocalUnicast() {
+			continue
+		}
+		if ipNet.IP.To4() != nil {
+			if v4 == nil {
+				v4 = ipNet.IP
+			}
+		} else if v6 == nil {
+			v6 = ipNet.IP
+		}
+	}
+
+	ip := net.ParseIP(host)
+	switch {
+	case ip != nil && ip.To4() == nil:
+		if v6 != nil {
+			return v6, nil
+		}
+		return nil, &interfaceError{name, "no usable IPv6 address"}
+	case v4 != nil:
+		return v4, nil
+	case v6 != nil && ip == nil:
+		// A hostname can still resolve to an IPv6 address
+		return v6, nil
+	}
+	return nil, &interfaceError{name, "no usable IPv4 address"}
+}
+
+// dialFromInterface returns a dialer that binds its connections to the
+// address of the named interface, so checks leave through that NIC on a
+// multi-homed host.
+func (m *Monitor) dialFromInterface(name string) dialFunc {
+	return func(ctx context.Context, network, address string) (net.Conn, error) {
+		host, _, err := net.SplitHostPort(address)
+		if err != nil {
+			return nil, err
+		}
+		local, err := interfaceAddr(name, host)
+		if err != nil {
+			return nil, err
+		}
+		dialer := m.newDialer()
+		dialer.LocalAddr = &net.TCPAddr{IP: local}
+		return dialer.DialContext(ctx, network, address)
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// isJSONC reports whether a config file is commented JSON, by extension.
+func isJSONC(filename string) bool {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".jsonc", ".json5":
+		return true
+	}
+	return false
+}
+
+// stripJSONC turns commented JSON into plain JSON by blanking out // and
+// /* */ comments and trailing commas before a closing } or ]. Everything
+// removed is replaced with spaces (newlines are kept), so decoding errors
+// still point at the right line and offset.
+func stripJSONC(data []byte) []byte {
+	out := append([]byte(nil), data...)
+	blank := func(from, to int) {
+		for i := from; i < to; i++ {
+			if out[i] != '\n' {
+				out[i] = ' '
+			}
+		}
+	}
+
+	comma := -1 // offset of a comma that may turn out to be trailing
+	for i := 0; i < len(out); i++ {
+		switch c := out[i]; {
+		case c == '"':
+			comma = -1
+			// Skip the string, including escaped quotes
+			for i++; i < len(out) && out[i] != '"'; i++ {
+				if out[i] == '\\' {
+					i++
+				}
+			}
+		case c == '/' && i+1 < len(out) && out[i+1] == '/':
+			end := i
+			for end < len(out) && out[end] != '\n' {
+				end++
+			}
+			blank(i, end)
+			i = end - 1
+		case c == '/' && i+1 < len(out) && out[i+1] == '*':
+			end := strings.Index(string(out[i+2:]), "*/")
+			if end < 0 {
+				// Left for the decoder to report
+				return out
+			}
+			blank(i, i+2+end+2)
+			i += 2 + end + 1
+		case c == ',':
+			comma = i
+		case c == '}' || c == ']':
+			if comma >= 0 {
+				out[comma] = ' '
+			}
+			comma = -1
+		case c == ' ' || c == '\t' || c == '\r' || c == '\n':
+		default:
+			comma = -1
+		}
+	}
+	return out
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"time"
+)
+
+const (
+	kafkaAPIVersionsKey           = 18
+	kafkaUnsupportedVersion       = 35
+	kafkaClientID                 = "server-health-monitor"
+	kafkaMaxResponseBytes         = 1 << 16
+	kafkaCorrelationID      int32 = 1
+)
+
+// KafkaInfo is what a kafka check learned from the broker's ApiVersions
+// response.
+type KafkaInfo struct {
+	APIs           int   `json:"apis"`             // number of APIs the broker serves
+	APIVersionsMax int16 `json:"api_versions_max"` // newest ApiVersions version, a proxy for the broker release
+}
+
+// checkKafka sends a Kafka ApiVersions (v0) request, which every broker
+// answers before authentication, to confirm the broker is serving the
+// Kafka protocol rather than merely accepting TCP connections.
+func (m *Monitor) checkKafka(ctx context.Context, server ServerConfig) HealthResult {
+	start := time.Now()
+	address := net.JoinHostPort(server.Host, strconv.Itoa(server.Port))
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(server.Timeout)*time.Second)
+	defer cancel()
+
+	var info *KafkaInfo
+	conn, err := m.dialerFor(server)(ctx, "tcp", address)
+	if err == nil {
+		deadline, _ := ctx.Deadline()
+		conn.SetDeadline(deadline)
+		info, err = kafkaAPIVersions(conn)
+		conn.Close()
+	}
+
+	result := HealthResult{
+		Server:       server,
+		ResponseTime: time.Since(start).Milliseconds(),
+		Timestamp:    time.Now(),
+		Kafka:        info,
+	}
+	if err != nil {
+		setFailure(&result, err)
+	} else {
+		result.Status = "UP"
+	}
+	return result
+}
+
+// kafkaError is a malformed or failed Kafka protocol exchange.
+type kafkaError struct {
+	reason string
+}
+
+func (e *kafkaError) Error() string {
+	return "kafka: " + e.reason
+}
+
+// kafkaAPIVersions performs the ApiVersions exchange on conn.
+func kafkaAPIVersions(conn net.Conn) (*KafkaInfo, error) {
+	// Request header v1 (api key, api version, correlation id, client id);
+	// the v0 request has no body
+	request := binary.BigEndian.AppendUint16(nil, kafkaAPIVersionsKey)
+	request = binary.BigEndian.AppendUint16(request, 0)
+	request = binary.BigEndian.AppendUint32(request, uint32(kafkaCorrelationID))
+	request = binary.BigEndian.AppendUint16(request, uint16(len(kafkaClientID)))
+	request = append(request, kafkaClientID...)
+	frame := binary.BigEndian.AppendUint32(nil, uint32(len(request)))
+	if _, err := conn.Write(append(frame, request...)); err != nil {
+		return nil, err
+	}
+
+	var size int32
+	if err := binary.Read(conn, binary.BigEndian, &size); err != nil {
+		return nil, err
+	}
+	if size < 6 || size > kafkaMaxResponseBytes {
+		return nil, &kafkaError{fmt.Sprintf("implausible response size %d", size)}
+	}
+	response := make([]byte, size)
+	if _, err := io.ReadFull(conn, response); err != nil {
+		return nil, err
+	}
+
+	if id := int32(binary.BigEndian.Uint32(response)); id != kafkaCorrelationID {
+		return nil, &kafkaError{fmt.Sprintf("unexpected correlation id %d", id)}
+	}
+	code := int16(binary.BigEndian.Uint16(response[4:]))
+	// A broker that has dropped v0 still answers, with its supported
+	// versions and UNSUPPORTED_VERSION, which proves it is serving
+	if code != 0 && code != kafkaUnsupportedVersion {
+		return nil, &kafkaError{fmt.Sprintf("ApiVersions error code %d", code)}
+	}
+
+	info := &KafkaInfo{}
+	body := response[6:]
+	if len(body) < 4 {
+		return info, nil
+	}
+	count := int(int32(binary.BigEndian.Uint32(body)))
+	body = body[4:]
+	for i := 0; i < count && len(body) >= 6; i++ {
+		key := int16(binary.BigEndian.Uint16(body))
+		maxVersion := int16(binary.BigEndian.Uint16(body[4:]))
+		if key == kafkaAPIVersionsKey {
+			info.APIVersionsMax = maxVersion
+		}
+		info.APIs++
+		body = body[6:]
+	}
+	return info, nil
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// defaultIcons are printed before each console result line.
+var defaultIcons = map[string]string{
+	"UP":       "✓",
+	"DOWN":     "✗",
+	"DEGRADED": "!",
+	"PAUSED":   "⏸",
+	"SKIPPED":  "-",
+}
+
+// parseStatusStrings parses a -icons/-labels value such as
+// "UP=OK,DOWN=FAIL" into overrides keyed by status.
+func parseStatusStrings(flag, spec string) (map[string]string, error) {
+	overrides := make(map[string]string)
+	for _, pair := range strings.Split(spec, ",") {
+		status, text, ok := strings.Cut(pair, "=")
+		status = strings.ToUpper(strings.TrimSpace(status))
+		if !ok {
+			return nil, fmt.Errorf("invalid %s %q: expected STATUS=text", flag, pair)
+		}
+		if _, known := defaultIcons[status]; !known {
+			return nil, fmt.Errorf("invalid %s %q: unknown status %q", flag, pair, status)
+		}
+		overrides[status] = text
+	}
+	return overrides, nil
+}
+
+// icon returns the console icon for status.
+func (m *Monitor) icon(status string) string {
+	if icon, ok := m.icons[status]; ok {
+		return icon
+	}
+	if icon, ok := defaultIcons[status]; ok {
+		return icon
+	}
+	return defaultIcons["UP"]
+}
+
+// label returns the console label for status; reports and JSON output
+// always use the status itself.
+func (m *Monitor) label(status string) string {
+	if label, ok := m.labels[status]; ok {
+		return label
+	}
+	return status
+}
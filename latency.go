@@ -0,0 +1,73 @@
+package main
+
+import (
+	"sort"
+	"time"
+)
+
+const (
+	// defaultLatencyWindow is how far back the rolling percentile looks
+	defaultLatencyWindow = 5 * time.Minute
+	// latencySamples bounds the per-server response time history
+	latencySamples = 512
+	// minLatencySamples is how many samples the window needs before a
+	// percentile is meaningful enough to alert on
+	minLatencySamples = 5
+)
+
+type latencySample struct {
+	at time.Time
+	ms int64
+}
+
+// latencyWindow is a fixed-size ring buffer of recent response times.
+type latencyWindow struct {
+	samples [latencySamples]latencySample
+	next    int
+	count   int
+}
+
+func (w *latencyWindow) add(at time.Time, ms int64) {
+	w.samples[w.next] = latencySample{at: at, ms: ms}
+	w.next = (w.next + 1) % latencySamples
+	if w.count < latencySamples {
+		w.count++
+	}
+}
+
+// percentile returns the p-th percentile (nearest rank) of the samples taken
+// after since, along with how many samples that covered.
+func (w *latencyWindow) percentile(p float64, since time.Time) (int64, int) {
+	var values []int64
+	for i := 0; i < w.count; i++ {
+		if sample := w.samples[i]; sample.at.After(since) {
+			values = append(values, sample.ms)
+		}
+	}
+	if len(values) == 0 {
+		return 0, 0
+	}
+
+	sort.Slice(values, func(i, j int) bool { return values[i] < values[j] })
+	rank := int(p/100*float64(len(values)) + 0.999999)
+	if rank < 1 {
+		rank = 1
+	}
+	if rank > len(values) {
+		rank = len(values)
+	}
+	return values[rank-1], len(values)
+}
+
+// recent returns up to n of the latest samples, oldest first.
+func (w *latencyWindow) recent(n int) []int64 {
+	if n > w.count {
+		n = w.count
+	}
+	values := make([]int64, n)
+	for i := 0; i < n; i++ {
+		idx := (w.next - n + i + latencySamples) % latencySamples
+		values[i] = w.samples[idx].ms
+	}
+	return values
+}
@@ -1,15 +1,29 @@
 package main
 
 import (
+	"bytes"
+	"context"
+	"crypto/tls"
 	"encoding/json"
 	"fmt"
+	"io"
 	"log"
 	"net"
 	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptrace"
 	"os"
+	"os/signal"
+	"path/filepath"
 	"runtime"
+	"slices"
+	"sort"
 	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
+	"syscall"
+	"text/template"
 	"time"
 )
 
@@ -17,249 +31,1644 @@ type ServerConfig struct {
 	Name     string `json:"name"`
 	Host     string `json:"host"`
 	Port     int    `json:"port"`
-	Protocol string `json:"protocol"` // "tcp", "http", "https"
+	Protocol string `json:"protocol"` // "tcp", "http", "https", "dns", "kafka"
 	Timeout  int    `json:"timeout"`  // seconds
+
+	// UP checks slower than this many milliseconds are reported DEGRADED;
+	// only the full Timeout makes a check DOWN
+	SoftTimeoutMs int `json:"soft_timeout_ms,omitempty"`
+
+	// Probes per check, overriding -probe-count; the result is UP only if
+	// most of them succeed, with their average response time
+	ProbeCount int `json:"probe_count,omitempty"`
+
+	// Extra attempts for a DOWN check, each after a random backoff of up
+	// to RetryBackoffBase*2^n ms capped at RetryBackoffMax ms
+	Retries          int `json:"retries,omitempty"`
+	RetryBackoffBase int `json:"retry_backoff_base_ms,omitempty"` // default 100
+	RetryBackoffMax  int `json:"retry_backoff_max_ms,omitempty"`  // default 5000
+
+	// Protocol/port pairs checked together instead of Protocol and Port;
+	// UP only if every one of them is
+	Checks []SubCheck `json:"checks,omitempty"`
+
+	// Fallback hosts tried in order instead of Host; UP if any one answers
+	Hosts []string `json:"hosts,omitempty"`
+
+	// Nameserver (host or host:port) used to resolve Host instead of the
+	// system resolver, e.g. for split-horizon DNS
+	Resolver string `json:"resolver,omitempty"`
+
+	// Network interface (e.g. "eth1") TCP and HTTP checks connect from,
+	// on multi-homed hosts; ignored with -socks5
+	Interface string `json:"interface,omitempty"`
+
+	// Concurrency pool the check runs in (default: the shared pool)
+	Pool string `json:"pool,omitempty"`
+
+	// Team or area the server belongs to, for -group-by group
+	Group string `json:"group,omitempty"`
+
+	// Labels used to select a subset of servers, e.g. by POST /check?tag=
+	Tags []string `json:"tags,omitempty"`
+
+	// Name of a server this one needs; while that server is not UP this
+	// one is reported as SKIPPED instead of being checked and alerted on
+	DependsOn string `json:"depends_on,omitempty"`
+
+	// Set to false to keep an entry in the config without checking it
+	Enabled *bool `json:"enabled,omitempty"`
+
+	// DNS checks only: the resolved records must include these values
+	ExpectIP    string `json:"expect_ip,omitempty"`
+	ExpectCNAME string `json:"expect_cname,omitempty"`
+
+	// DNS checks only: the TTL (seconds) of the A/AAAA records must be
+	// within these bounds; outside them the result is DEGRADED
+	MinTTL *int `json:"min_ttl,omitempty"`
+	MaxTTL *int `json:"max_ttl,omitempty"`
+
+	// TCP checks only: bytes written after connecting, and what the
+	// response must start with and/or match
+	SendPayload        string `json:"send_payload,omitempty"`
+	ExpectPayload      string `json:"expect_payload,omitempty"`
+	ExpectPayloadRegex string `json:"expect_payload_regex,omitempty"`
+
+	// Continuous mode only: consecutive successes/failures required before
+	// the server's state flips to UP/DOWN (default 1)
+	RiseThreshold int `json:"rise_threshold,omitempty"`
+	FallThreshold int `json:"fall_threshold,omitempty"`
+
+	// Continuous mode only: alert when the rolling p95 response time over
+	// the latency window exceeds this many milliseconds
+	LatencyP95Ms int `json:"latency_p95_ms,omitempty"`
+
+	// Expected response time; UP results slower than anomalyFactor times
+	// this are flagged as anomalous
+	BaselineMs int `json:"baseline_ms,omitempty"`
+
+	// HTTP checks only. Header values of the form "@/path" and PasswordFile
+	// are read from files at load time so secrets stay out of the config.
+	Headers      map[string]string `json:"headers,omitempty"`
+	Username     string            `json:"username,omitempty"`
+	Password     string            `json:"password,omitempty"`
+	PasswordFile string            `json:"password_file,omitempty"`
+	Path         string            `json:"path,omitempty"`          // request path, default "/"
+	URL          string            `json:"url,omitempty"`           // template such as "https://{host}:{port}/health"
+	Cookies      map[string]string `json:"cookies,omitempty"`       // sent with the request; "@/path" values too
+	Login        *LoginConfig      `json:"login,omitempty"`         // POSTed first to get a session cookie
+	MaxRedirects int               `json:"max_redirects,omitempty"` // default 10
+	ServerName   string            `json:"server_name,omitempty"`   // TLS SNI and Host header, when Host is an IP
+	ExpectBody   string            `json:"expect_body,omitempty"`   // substring the (decompressed) body must contain
+	ExpectJSON   map[string]string `json:"expect_json,omitempty"`   // dotted path -> value the JSON body must hold
+
+	// File whose contents the whole response body must equal, ignoring
+	// leading and trailing whitespace; read when the config is loaded
+	ExpectBodyFile string `json:"expect_body_file,omitempty"`
+
+	// Response headers that must be present, with an exact value unless
+	// the expected value is empty
+	ExpectHeaders map[string]string `json:"expect_headers,omitempty"`
+
+	// Status codes that mean the server is struggling rather than dead,
+	// e.g. 429 or 503; they are DEGRADED instead of DOWN
+	DegradedStatus []int `json:"degraded_status,omitempty"`
+
+	// HTTPS checks only: the server must staple a good OCSP response.
+	// Missing, unknown or stale stapling is DEGRADED; revocation is DOWN.
+	RequireOCSP bool `json:"require_ocsp,omitempty"`
+
+	// HTTPS checks only: a substring of the certificate issuer's DN and a
+	// name that must be among its SANs; a mismatch is DOWN
+	ExpectIssuer string `json:"expect_issuer,omitempty"`
+	ExpectSAN    string `json:"expect_san,omitempty"`
+
+	// HTTP checks only: another endpoint, e.g. the stable deployment of
+	// this canary, whose response must differ from this one's; a match is
+	// DEGRADED
+	DifferFrom *DifferFrom `json:"differ_from,omitempty"`
+
+	// Static context for responders (owner, runbook, severity) copied
+	// into results and alerts
+	Annotations map[string]string `json:"annotations,omitempty"`
+
+	// When to check the server, e.g. "Mon-Fri 08:00-18:00 Europe/Berlin";
+	// outside it the server is SKIPPED, so it neither alerts nor counts
+	// as DOWN. Checked around the clock when empty.
+	Schedule string `json:"schedule,omitempty"`
+
+	secretHeaders []string     // headers whose values came from secret files
+	dialIP        string       // address to connect to instead of resolving Host
+	hours         *activeHours // parsed Schedule
+	expectBody    []byte       // contents of ExpectBodyFile
 }
 
 type HealthResult struct {
-	Server      ServerConfig `json:"server"`
-	Status      string       `json:"status"`      // "UP", "DOWN"
-	ResponseTime int64       `json:"response_time"` // milliseconds
-	Timestamp   time.Time    `json:"timestamp"`
-	Error       string       `json:"error,omitempty"`
+	Server        ServerConfig `json:"server"`
+	Status        string       `json:"status"`        // "UP", "DEGRADED", "DOWN"
+	ResponseTime  int64        `json:"response_time"` // milliseconds
+	Timestamp     time.Time    `json:"timestamp"`
+	Error         string       `json:"error,omitempty"`
+	ErrorCategory string       `json:"error_category,omitempty"` // e.g. "dns timeout", "connect refused"
+	ResolvedAddrs []string     `json:"resolved_addrs,omitempty"` // DNS checks only
+	TTL           *uint32      `json:"ttl,omitempty"`            // DNS checks with MinTTL/MaxTTL
+	BytesRead     int64        `json:"bytes_read,omitempty"`     // HTTP response body size
+	StatusCode    int          `json:"status_code,omitempty"`    // HTTP checks only
+	AnsweredBy    string       `json:"answered_by,omitempty"`    // host that answered, with Hosts
+	CheckedIP     string       `json:"checked_ip,omitempty"`     // address dialed, with -resolve
+	FinalURL      string       `json:"final_url,omitempty"`      // where HTTP redirects ended up
+	Compressed    bool         `json:"compressed,omitempty"`     // HTTP body was gzip/deflate encoded
+	BodyTruncated bool         `json:"body_truncated,omitempty"` // HTTP body exceeded -max-body-bytes
+	OCSPStatus    string       `json:"ocsp_status,omitempty"`    // stapled OCSP status, with RequireOCSP
+	CertIssuer    string       `json:"cert_issuer,omitempty"`    // with ExpectIssuer/ExpectSAN
+	CertSANs      []string     `json:"cert_sans,omitempty"`      // with ExpectIssuer/ExpectSAN
+	SubResults    []SubResult  `json:"sub_results,omitempty"`    // per sub-check detail, with Checks
+	Anomaly       bool         `json:"anomaly,omitempty"`        // UP but slower than anomalyFactor x BaselineMs
+	Timing        *Timing      `json:"timing,omitempty"`         // phase breakdown, with -cold or TCP to a name
+	Probes        *ProbeStats  `json:"probes,omitempty"`         // with a probe count above 1
+	Kafka         *KafkaInfo   `json:"kafka,omitempty"`          // kafka checks only
+	Attempts      int          `json:"attempts,omitempty"`       // checks made, with Retries and a retry
+	RetryMs       int64        `json:"retry_ms,omitempty"`       // time spent on backoff and retries
+	Compared      *Comparison  `json:"compared,omitempty"`       // values compared, with DifferFrom
+	BudgetMs      int64        `json:"budget_ms,omitempty"`      // timeout given, with -healthcheck-timeout-budget
+
+	Annotations map[string]string `json:"annotations,omitempty"` // copied from the server config
+	Regression  string            `json:"regression,omitempty"`  // how it got worse than in -baseline
+
+	bodySnippet string // start of the HTTP response body, for -explain
+}
+
+// address is the host:port shown for a result; composite servers list
+// each sub-check's port.
+func (r HealthResult) address() string {
+	if len(r.Server.Checks) == 0 {
+		return fmt.Sprintf("%s:%d", r.host(), r.Server.Port)
+	}
+	ports := make([]string, len(r.Server.Checks))
+	for i, sub := range r.Server.Checks {
+		ports[i] = fmt.Sprintf("%s/%d", sub.Protocol, sub.Port)
+	}
+	return fmt.Sprintf("%s:{%s}", r.host(), strings.Join(ports, ","))
+}
+
+// up reports whether the server answered, possibly slowly (DEGRADED).
+func (r HealthResult) up() bool {
+	return r.Status == "UP" || r.Status == "DEGRADED"
+}
+
+// host is the host to show for a result: the one that answered for
+// entries with fallback hosts, otherwise the configured host.
+func (r HealthResult) host() string {
+	if r.AnsweredBy != "" {
+		return r.AnsweredBy
+	}
+	if r.Server.Host == "" && len(r.Server.Hosts) > 0 {
+		return strings.Join(r.Server.Hosts, "|")
+	}
+	return r.Server.Host
 }
 
+const (
+	// defaultMaxBodyBytes caps how much of an HTTP response body is read
+	// unless -max-body-bytes says otherwise
+	defaultMaxBodyBytes = 1 << 20
+	// defaultMaxRedirects matches net/http's own limit
+	defaultMaxRedirects = 10
+	// defaultMinInterval is the shortest continuous interval accepted
+	// without -min-interval
+	defaultMinInterval = time.Second
+	// anomalyFactor is how many times BaselineMs an UP check may take
+	// before it is flagged as an anomaly
+	anomalyFactor = 3
+)
+
 type Monitor struct {
-	servers []ServerConfig
-	results chan HealthResult
-	wg      sync.WaitGroup
+	servers  []ServerConfig
+	disabled int       // servers skipped because they are disabled in the config
+	excluded int       // servers left out by -exclude or -exclude-tag
+	out      io.Writer // console output of check rounds
+	started  time.Time // when the monitor was created, for the session summary
+
+	// Config file the servers came from, or e.g. "command-line targets"
+	configSource string
+
+	// "concurrency" from the config file, 0 if unset; -concurrency wins
+	configConcurrency int
+
+	// maxConcurrency bounds the number of checks in flight at once in the
+	// default pool, and in named pools without their own limit
+	maxConcurrency int
+	poolLimits     map[string]int
+	useColor       bool
+	failFast       bool // cancel the rest of a round on the first DOWN
+	timeFormat     string
+	location       *time.Location
+	dial           dialFunc // nil dials directly
+	strictConfig   bool     // reject unknown config fields
+	jsonc          bool     // allow comments and trailing commas in every config file
+	waitFirstTick  bool     // skip the immediate check when continuous mode starts
+	sortBy         string   // buffer and order round output, see sortKeys
+	maxResults     int      // cap on results listed in a report, 0 for all
+	groupBy        string   // nest report results by this field, see groupKeys
+	compact        bool     // write reports without indentation
+	maxBodyBytes   int64    // most HTTP response body bytes read per check
+	perFamily      bool     // check each resolved address family separately
+	allAddrs       bool     // check every resolved address separately
+	probes         int      // -probe-count: probes per check, unless the server sets ProbeCount
+	dedupe         bool     // probe identical targets once per round
+	cold           bool     // no connection reuse or DNS caching; record Timing
+	alertTemplate  *template.Template
+	notifiers      []notifier
+	syslog         *syslogSink // -syslog: every result is logged too
+	latencyWindow  time.Duration
+	minInterval    time.Duration // shortest continuous interval allowed
+	graceUntil     time.Time     // DOWN results before this are ignored by state
+	stateFile      string        // -state: alert state saved on shutdown
+	configWatch    string        // -config-watch: config file reloaded when it changes
+	preHook        string        // shell command run before each continuous round
+	postHook       string        // shell command run after it, given the results
+	hookTimeout    time.Duration
+
+	baseline         map[string]HealthResult // -baseline results by server name
+	baselineSlowdown float64                 // percent slower than baseline that is a regression
+
+	excludeNames map[string]bool // -exclude: servers not to check
+	excludeTags  map[string]bool // -exclude-tag: tags of servers not to check
+
+	watchdog *errorRateWatchdog // -fail-on-error-rate, in continuous mode
+
+	// -healthcheck-timeout-budget: each round is to finish within this
+	timeoutBudget time.Duration
+
+	icons  map[string]string // -icons overrides of defaultIcons
+	labels map[string]string // -labels overrides of the status names
+
+	roundMu         sync.Mutex // continuous rounds run one at a time
+	slowRoundWarned bool       // a round has outlasted the interval
+
+	// -interval-<protocol> overrides of the continuous interval
+	protocolIntervals map[string]time.Duration
+
+	metrics atomic.Pointer[metricsSnapshot] // latest round, for /metrics
+
+	checkers map[string]Checker // by protocol, see RegisterChecker
+
+	recordMu sync.Mutex
+	recorder *recorder // -record: each round is appended for -replay
+
+	mu            sync.Mutex
+	states        map[string]*serverState
+	history       alertHistory    // recent state changes, for /alerts
+	rounds        int             // continuous rounds run, for the session summary
+	paused        bool            // all checks paused via the status endpoint
+	pausedServers map[string]bool // individually paused servers
 }
 
 func NewMonitor() *Monitor {
-	return &Monitor{
-		results: make(chan HealthResult, 100),
+	m := &Monitor{
+		out:            os.Stdout,
+		started:        time.Now(),
+		maxConcurrency: defaultConcurrency(),
+		timeFormat:     "15:04:05",
+		location:       time.Local,
+		latencyWindow:  defaultLatencyWindow,
+		minInterval:    defaultMinInterval,
+		hookTimeout:    defaultHookTimeout,
+		maxBodyBytes:   defaultMaxBodyBytes,
+		states:         make(map[string]*serverState),
+		pausedServers:  make(map[string]bool),
+		poolLimits:     make(map[string]int),
+		alertTemplate:  mustDefaultAlertTemplate(),
+		checkers:       make(map[string]Checker),
 	}
+	m.registerBuiltinCheckers()
+	return m
 }
 
 func (m *Monitor) LoadConfig(filename string) error {
-	file, err := os.ReadFile(filename)
+	var settings configSettings
+	servers, err := m.loadConfigFile(filename, nil, make(map[string]string), &settings)
+	if err != nil {
+		return err
+	}
+	if err := m.setServers(servers); err != nil {
+		return err
+	}
+	m.configSource = filename
+	m.applySettings(settings)
+	return nil
+}
+
+// LoadDefaultConfig loads the built-in default servers from memory, without
+// touching the filesystem.
+func (m *Monitor) LoadDefaultConfig() error {
+	var settings configSettings
+	servers, err := m.parseConfig("built-in default config", bytes.NewReader(defaultConfig), nil,
+		make(map[string]string), &settings)
 	if err != nil {
-		return fmt.Errorf("failed to read config file: %v", err)
+		return err
+	}
+	if err := m.setServers(servers); err != nil {
+		return err
+	}
+	m.configSource = "built-in default config"
+	m.applySettings(settings)
+	return nil
+}
+
+func (m *Monitor) setServers(servers []ServerConfig) error {
+	enabled, disabled, err := validateServers(servers)
+	if err != nil {
+		return err
+	}
+	m.servers, m.excluded = m.excludeServers(enabled)
+	m.disabled = disabled
+	return nil
+}
+
+// validateServers checks a loaded config and returns its enabled servers,
+// with secrets resolved, and how many were disabled.
+func validateServers(servers []ServerConfig) ([]ServerConfig, int, error) {
+	if err := checkDependencies(servers); err != nil {
+		return nil, 0, err
+	}
+
+	var enabled []ServerConfig
+	disabled := 0
+	for _, server := range servers {
+		if !server.enabled() {
+			disabled++
+			continue
+		}
+		if err := resolveSecrets(&server); err != nil {
+			return nil, 0, fmt.Errorf("server %q: %v", server.Name, err)
+		}
+		if server.URL != "" {
+			// Fail at load time rather than on every check
+			if _, err := expandURL(server); err != nil {
+				return nil, 0, fmt.Errorf("server %q: %v", server.Name, err)
+			}
+		}
+		if server.ExpectBodyFile != "" {
+			if err := loadExpectBodyFile(&server); err != nil {
+				return nil, 0, fmt.Errorf("server %q: %v", server.Name, err)
+			}
+		}
+		if server.DifferFrom != nil {
+			if err := validateDifferFrom(server); err != nil {
+				return nil, 0, fmt.Errorf("server %q: %v", server.Name, err)
+			}
+		}
+		if server.Schedule != "" {
+			hours, err := parseSchedule(server.Schedule)
+			if err != nil {
+				return nil, 0, fmt.Errorf("server %q: %v", server.Name, err)
+			}
+			server.hours = hours
+		}
+		enabled = append(enabled, server)
+	}
+	return enabled, disabled, nil
+}
+
+// enabled reports whether the server should be checked; entries are enabled
+// unless they explicitly set "enabled": false.
+func (s ServerConfig) enabled() bool {
+	return s.Enabled == nil || *s.Enabled
+}
+
+// loadConfigFile parses one config file and, recursively, the files it
+// includes (resolved relative to it). chain holds the files currently being
+// loaded, to detect cycles, and names maps each server name to the file that
+// defined it, to detect duplicates across files. The file's top-level
+// settings are stored in settings unless it is nil, as for included files.
+func (m *Monitor) loadConfigFile(filename string, chain []string, names map[string]string,
+	settings *configSettings) ([]ServerConfig, error) {
+	abs, err := filepath.Abs(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %v", err)
+	}
+	for i, loading := range chain {
+		if loading == abs {
+			cycle := append(append([]string{}, chain[i:]...), abs)
+			return nil, fmt.Errorf("cyclic include: %s", strings.Join(cycle, " -> "))
+		}
+	}
+	chain = append(chain, abs)
+
+	if m.jsonc || isJSONC(filename) {
+		data, err := os.ReadFile(filename)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read config file: %v", err)
+		}
+		return m.parseConfig(filename, bytes.NewReader(stripJSONC(data)), chain, names, settings)
+	}
+
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %v", err)
+	}
+	defer file.Close()
+	return m.parseConfig(filename, file, chain, names, settings)
+}
+
+// parseConfig decodes the config data read from filename and loads its
+// includes; see loadConfigFile.
+func (m *Monitor) parseConfig(filename string, r io.Reader, chain []string, names map[string]string,
+	settings *configSettings) ([]ServerConfig, error) {
+	// Unknown fields are ignored by default so newer configs keep working
+	// with older binaries; -strict-config turns typos into errors instead
+	decoder := json.NewDecoder(r)
+	if m.strictConfig {
+		decoder.DisallowUnknownFields()
+	}
+
+	var decoded configSettings
+	servers, includes, err := decodeConfig(decoder, m.strictConfig, &decoded)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse config %s: %v", filename, err)
+	}
+	if decoded.Concurrency != nil && *decoded.Concurrency < 1 {
+		return nil, fmt.Errorf("invalid concurrency %d in %s: must be positive", *decoded.Concurrency, filename)
+	}
+	// Only the file given to -config has settings, not the files it includes
+	if settings != nil {
+		*settings = decoded
+	}
+
+	for _, server := range servers {
+		if other, ok := names[server.Name]; ok {
+			return nil, fmt.Errorf("duplicate server name %q in %s (already defined in %s)",
+				server.Name, filename, other)
+		}
+		names[server.Name] = filename
+	}
+
+	for _, include := range includes {
+		if !filepath.IsAbs(include) {
+			include = filepath.Join(filepath.Dir(filename), include)
+		}
+		included, err := m.loadConfigFile(include, chain, names, nil)
+		if err != nil {
+			return nil, err
+		}
+		servers = append(servers, included...)
+	}
+
+	return servers, nil
+}
+
+// configSettings are the top-level config fields other than "servers" and
+// "include".
+type configSettings struct {
+	Concurrency *int // checks in flight at once, unless -concurrency is given
+}
+
+// applySettings makes a loaded and validated config's settings current.
+func (m *Monitor) applySettings(settings configSettings) {
+	m.configConcurrency = 0
+	if settings.Concurrency != nil {
+		m.configConcurrency = *settings.Concurrency
 	}
+}
+
+// decodeConfig walks the top-level config object token by token, decoding
+// the "servers" array one entry at a time so generated configs with tens of
+// thousands of servers never have to be held in memory as raw JSON. Other
+// top-level fields are decoded into settings.
+func decodeConfig(decoder *json.Decoder, strict bool, settings *configSettings) ([]ServerConfig, []string, error) {
+	if err := expectDelim(decoder, '{'); err != nil {
+		return nil, nil, err
+	}
+
+	var servers []ServerConfig
+	var includes []string
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, nil, err
+		}
+		key, _ := token.(string)
 
-	var config struct {
-		Servers []ServerConfig `json:"servers"`
+		switch key {
+		case "include":
+			if err := decoder.Decode(&includes); err != nil {
+				return nil, nil, err
+			}
+		case "concurrency":
+			if err := decoder.Decode(&settings.Concurrency); err != nil {
+				return nil, nil, err
+			}
+		case "servers":
+			token, err := decoder.Token()
+			if err != nil {
+				return nil, nil, err
+			}
+			if token == nil {
+				continue // "servers": null
+			}
+			if delim, ok := token.(json.Delim); !ok || delim != '[' {
+				return nil, nil, fmt.Errorf("servers: expected an array, found %v", token)
+			}
+			for decoder.More() {
+				var server ServerConfig
+				if err := decoder.Decode(&server); err != nil {
+					return nil, nil, err
+				}
+				servers = append(servers, server)
+			}
+			if err := expectDelim(decoder, ']'); err != nil {
+				return nil, nil, err
+			}
+		default:
+			if strict {
+				return nil, nil, fmt.Errorf("json: unknown field %q", key)
+			}
+			var skip json.RawMessage
+			if err := decoder.Decode(&skip); err != nil {
+				return nil, nil, err
+			}
+		}
 	}
 
-	if err := json.Unmarshal(file, &config); err != nil {
-		return fmt.Errorf("failed to parse config: %v", err)
+	if err := expectDelim(decoder, '}'); err != nil {
+		return nil, nil, err
 	}
+	return servers, includes, nil
+}
 
-	m.servers = config.Servers
+func expectDelim(decoder *json.Decoder, want json.Delim) error {
+	token, err := decoder.Token()
+	if err != nil {
+		return err
+	}
+	if delim, ok := token.(json.Delim); !ok || delim != want {
+		return fmt.Errorf("expected %q, found %v", want, token)
+	}
 	return nil
 }
 
-func (m *Monitor) checkTCP(server ServerConfig) HealthResult {
+// dialContext dials through the configured proxy, if any.
+func (m *Monitor) dialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	if m.dial != nil {
+		return m.dial(ctx, network, address)
+	}
+	dialer := m.newDialer()
+	return dialer.DialContext(ctx, network, address)
+}
+
+// newDialer returns the dialer for direct connections.
+func (m *Monitor) newDialer() net.Dialer {
+	var dialer net.Dialer
+	if m.cold {
+		// A resolver of our own per check, so nothing is answered from
+		// a cache shared with earlier checks
+		dialer.Resolver = &net.Resolver{PreferGo: true}
+	}
+	return dialer
+}
+
+// dialerFor returns the dialer for a server's checks, which connects to its
+// pinned address (see -resolve) instead of resolving the host when it has one,
+// and from its Interface when it names one.
+func (m *Monitor) dialerFor(server ServerConfig) dialFunc {
+	dial := m.dialContext
+	if server.Interface != "" && m.dial == nil {
+		dial = m.dialFromInterface(server.Interface)
+	}
+	if server.dialIP == "" {
+		if server.Resolver != "" {
+			return m.dialViaResolver(server, dial)
+		}
+		return dial
+	}
+	return func(ctx context.Context, network, address string) (net.Conn, error) {
+		_, port, err := net.SplitHostPort(address)
+		if err != nil {
+			return nil, err
+		}
+		return dial(ctx, network, net.JoinHostPort(server.dialIP, port))
+	}
+}
+
+func (m *Monitor) checkTCP(ctx context.Context, server ServerConfig) HealthResult {
 	start := time.Now()
 	address := net.JoinHostPort(server.Host, strconv.Itoa(server.Port))
-	
-	conn, err := net.DialTimeout("tcp", address, time.Duration(server.Timeout)*time.Second)
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(server.Timeout)*time.Second)
+	defer cancel()
+	dial := m.dialerFor(server)
+	var timing *Timing
+	if m.dial == nil && server.dialIP == "" && net.ParseIP(server.Host) == nil {
+		timing = &Timing{}
+		ctx = httptrace.WithClientTrace(ctx, dialTrace(timing))
+	}
+	conn, err := dial(ctx, "tcp", address)
+	if err == nil {
+		deadline, _ := ctx.Deadline()
+		err = probePayload(conn, server, deadline)
+		conn.Close()
+	} else {
+		// Phases of a failed dial would be misleading
+		timing = nil
+	}
 	responseTime := time.Since(start).Milliseconds()
 	
 	result := HealthResult{
 		Server:       server,
 		ResponseTime: responseTime,
 		Timestamp:    time.Now(),
+		Timing:       timing,
 	}
 
 	if err != nil {
-		result.Status = "DOWN"
-		result.Error = err.Error()
+		setFailure(&result, err)
 	} else {
 		result.Status = "UP"
-		conn.Close()
 	}
 
 	return result
 }
 
-func (m *Monitor) checkHTTP(server ServerConfig) HealthResult {
+func (m *Monitor) checkHTTP(ctx context.Context, server ServerConfig) HealthResult {
 	start := time.Now()
-	url := fmt.Sprintf("%s://%s:%d", server.Protocol, server.Host, server.Port)
+	base := fmt.Sprintf("%s://%s:%d", server.Protocol, server.Host, server.Port)
+	url := base + requestPath(server.Path)
+	if server.URL != "" {
+		expanded, err := expandURL(server)
+		if err != nil {
+			return HealthResult{
+				Server:    server,
+				Status:    "DOWN",
+				Timestamp: time.Now(),
+				Error:     err.Error(),
+			}
+		}
+		url = expanded.String()
+		base = expanded.Scheme + "://" + expanded.Host
+	}
 	
 	client := &http.Client{
 		Timeout: time.Duration(server.Timeout) * time.Second,
 	}
+	if server.Login != nil {
+		// Keeps the session cookies set by the login step
+		client.Jar, _ = cookiejar.New(nil)
+	}
+	if m.dial != nil || server.dialIP != "" || server.ServerName != "" || server.Resolver != "" ||
+		server.Interface != "" || m.cold {
+		transport := &http.Transport{
+			DialContext: m.dialerFor(server),
+			// Cold checks never reuse a connection from an earlier check
+			DisableKeepAlives: m.cold,
+		}
+		if server.ServerName != "" {
+			// Verify the certificate against ServerName rather than Host
+			transport.TLSClientConfig = &tls.Config{ServerName: server.ServerName}
+		}
+		client.Transport = transport
+	}
+
+	// The differ_from request is not part of the check's -cold timing
+	untraced := ctx
+	var timing *Timing
+	if m.cold {
+		timing = &Timing{}
+		ctx = httptrace.WithClientTrace(ctx, timingTrace(timing))
+	}
+
+	// Track where redirects lead, failing early once MaxRedirects is exceeded
+	finalURL := ""
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		finalURL = req.URL.String()
+		limit := server.MaxRedirects
+		if limit <= 0 {
+			limit = defaultMaxRedirects
+		}
+		if len(via) > limit {
+			return fmt.Errorf("too many redirects (more than %d)", limit)
+		}
+		return nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return HealthResult{
+			Server:    server,
+			Status:    "DOWN",
+			Timestamp: time.Now(),
+			Error:     err.Error(),
+		}
+	}
+	if server.ServerName != "" {
+		req.Host = server.ServerName
+	}
+	req.Header.Set("Accept-Encoding", acceptEncoding)
+	for name, value := range server.Headers {
+		req.Header.Set(name, value)
+	}
+	if server.Username != "" {
+		req.SetBasicAuth(server.Username, server.Password)
+	}
+	for name, value := range server.Cookies {
+		req.AddCookie(&http.Cookie{Name: name, Value: value})
+	}
+
+	if server.Login != nil {
+		if err := login(ctx, client, base, server); err != nil {
+			return HealthResult{
+				Server:        server,
+				Status:        "DOWN",
+				ResponseTime:  time.Since(start).Milliseconds(),
+				Timestamp:     time.Now(),
+				Error:         err.Error(),
+				ErrorCategory: "login",
+			}
+		}
+		// Only redirects of the check itself are of interest
+		finalURL = ""
+	}
 
-	resp, err := client.Get(url)
+	resp, err := client.Do(req)
 	responseTime := time.Since(start).Milliseconds()
 	
 	result := HealthResult{
 		Server:       server,
 		ResponseTime: responseTime,
 		Timestamp:    time.Now(),
+		FinalURL:     finalURL,
+		Timing:       timing,
 	}
 
 	if err != nil {
-		result.Status = "DOWN"
-		result.Error = err.Error()
+		setFailure(&result, err)
 	} else {
 		defer resp.Body.Close()
-		if resp.StatusCode >= 200 && resp.StatusCode < 400 {
-			result.Status = "UP"
-		} else {
+		read, bodyErr := readBody(resp, m.maxBodyBytes)
+		body := read.data
+		result.Compressed = read.compressed
+		result.BodyTruncated = read.truncated
+		result.BytesRead = int64(len(body))
+		if result.BytesRead == 0 && resp.ContentLength > 0 {
+			result.BytesRead = resp.ContentLength
+		}
+		result.StatusCode = resp.StatusCode
+		result.bodySnippet = snippet(body)
+		headerErr := matchHeaders(resp.Header, server.ExpectHeaders)
+		var jsonErr error
+		if len(server.ExpectJSON) > 0 {
+			jsonErr = bodyErr
+			if jsonErr == nil {
+				jsonErr = matchJSON(body, server.ExpectJSON)
+			}
+		}
+		var goldenErr error
+		if server.ExpectBodyFile != "" {
+			goldenErr = diffBody(server.expectBody, body)
+		}
+		var certErr error
+		if server.ExpectIssuer != "" || server.ExpectSAN != "" {
+			result.CertIssuer, result.CertSANs = peerCertificate(resp.TLS)
+			certErr = matchCertificate(server, result.CertIssuer, result.CertSANs)
+		}
+		var ocspErr error
+		if server.RequireOCSP {
+			result.OCSPStatus, ocspErr = ocspStatus(resp.TLS)
+		}
+		var compareErr error
+		canaryMatch := false
+		if server.DifferFrom != nil && resp.StatusCode >= 200 && resp.StatusCode < 400 {
+			compareErr = bodyErr
+			var value, other string
+			if compareErr == nil {
+				value, compareErr = comparedValue(server.DifferFrom, resp.Header, body)
+			}
+			if compareErr == nil {
+				other, compareErr = m.compareWith(untraced, server, req.Header)
+				if compareErr != nil {
+					compareErr = fmt.Errorf("%s: %v", server.DifferFrom.URL, compareErr)
+				}
+			}
+			if compareErr == nil {
+				result.Compared = &Comparison{Value: shortValue(value), Other: shortValue(other)}
+				canaryMatch = value == other
+			}
+		}
+		switch {
+		case slices.Contains(server.DegradedStatus, resp.StatusCode):
+			result.Status = "DEGRADED"
+			result.ErrorCategory = "http status"
+			result.Error = fmt.Sprintf("HTTP %d", resp.StatusCode)
+		case resp.StatusCode < 200 || resp.StatusCode >= 400:
 			result.Status = "DOWN"
+			result.ErrorCategory = "http status"
 			result.Error = fmt.Sprintf("HTTP %d", resp.StatusCode)
+		case server.ExpectBody != "" && bodyErr != nil:
+			result.Status = "DOWN"
+			result.ErrorCategory = "body mismatch"
+			result.Error = fmt.Sprintf("body mismatch: %v", bodyErr)
+		case server.ExpectBody != "" && !bytes.Contains(body, []byte(server.ExpectBody)):
+			result.Status = "DOWN"
+			result.ErrorCategory = "body mismatch"
+			result.Error = fmt.Sprintf("body mismatch: %q not found in response", server.ExpectBody)
+		case server.ExpectBodyFile != "" && bodyErr != nil:
+			result.Status = "DOWN"
+			result.ErrorCategory = "body mismatch"
+			result.Error = fmt.Sprintf("body mismatch: %v", bodyErr)
+		case server.ExpectBodyFile != "" && goldenErr != nil:
+			result.Status = "DOWN"
+			result.ErrorCategory = "body mismatch"
+			result.Error = fmt.Sprintf("body mismatch with %s: %v", server.ExpectBodyFile, goldenErr)
+		case headerErr != nil:
+			result.Status = "DOWN"
+			result.ErrorCategory = "header mismatch"
+			result.Error = fmt.Sprintf("header mismatch: %v", headerErr)
+		case jsonErr != nil:
+			result.Status = "DOWN"
+			result.ErrorCategory = "json mismatch"
+			result.Error = fmt.Sprintf("json mismatch: %v", jsonErr)
+		case certErr != nil:
+			result.Status = "DOWN"
+			result.ErrorCategory = "certificate mismatch"
+			result.Error = fmt.Sprintf("certificate mismatch: %v", certErr)
+		case result.OCSPStatus == ocspRevoked:
+			result.Status = "DOWN"
+			result.ErrorCategory = "ocsp"
+			result.Error = fmt.Sprintf("ocsp: %v", ocspErr)
+		case ocspErr != nil:
+			result.Status = "DEGRADED"
+			result.ErrorCategory = "ocsp"
+			result.Error = fmt.Sprintf("ocsp: %v", ocspErr)
+		case compareErr != nil:
+			result.Status = "DOWN"
+			result.ErrorCategory = "compare error"
+			result.Error = fmt.Sprintf("compare error: %v", compareErr)
+		case canaryMatch:
+			result.Status = "DEGRADED"
+			result.ErrorCategory = "canary match"
+			result.Error = fmt.Sprintf("canary match: %q is the same as at %s", result.Compared.Value, server.DifferFrom.URL)
+		default:
+			result.Status = "UP"
 		}
 	}
 
 	return result
 }
 
-func (m *Monitor) checkServer(server ServerConfig) {
-	defer m.wg.Done()
-	
-	var result HealthResult
-	
-	switch server.Protocol {
-	case "tcp":
-		result = m.checkTCP(server)
-	case "http", "https":
-		result = m.checkHTTP(server)
-	default:
-		result = HealthResult{
-			Server:    server,
-			Status:    "DOWN",
-			Timestamp: time.Now(),
-			Error:     "unsupported protocol: " + server.Protocol,
-		}
+func (m *Monitor) checkDNS(ctx context.Context, server ServerConfig) HealthResult {
+	start := time.Now()
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(server.Timeout)*time.Second)
+	defer cancel()
+
+	resolver := resolverFor(server)
+	addrs, err := resolver.LookupHost(ctx, server.Host)
+
+	result := HealthResult{
+		Server:        server,
+		Timestamp:     time.Now(),
+		ResolvedAddrs: addrs,
 	}
-	
-	m.results <- result
-}
 
-func (m *Monitor) RunCheck() {
-	fmt.Printf("Checking %d servers...\n", len(m.servers))
-	
-	// Start goroutines for concurrent checking
-	for _, server := range m.servers {
-		m.wg.Add(1)
-		go m.checkServer(server)
+	if err != nil {
+		result.ResponseTime = time.Since(start).Milliseconds()
+		setFailure(&result, err)
+		return result
 	}
 
-	// Close results channel when all checks complete
-	go func() {
-		m.wg.Wait()
-		close(m.results)
-	}()
+	if server.ExpectIP != "" && !containsIP(addrs, server.ExpectIP) {
+		result.ResponseTime = time.Since(start).Milliseconds()
+		result.Status = "DOWN"
+		result.ErrorCategory = "dns mismatch"
+		result.Error = fmt.Sprintf("expected IP %s not in %v", server.ExpectIP, addrs)
+		return result
+	}
 
-	// Collect and display results
-	var upCount, downCount int
-	for result := range m.results {
-		status := "✓"
-		if result.Status == "DOWN" {
-			status = "✗"
-			downCount++
-		} else {
-			upCount++
+	if server.ExpectCNAME != "" {
+		cname, err := resolver.LookupCNAME(ctx, server.Host)
+		if err != nil {
+			result.ResponseTime = time.Since(start).Milliseconds()
+			setFailure(&result, err)
+			return result
 		}
+		if !strings.EqualFold(strings.TrimSuffix(cname, "."), strings.TrimSuffix(server.ExpectCNAME, ".")) {
+			result.ResponseTime = time.Since(start).Milliseconds()
+			result.Status = "DOWN"
+			result.ErrorCategory = "dns mismatch"
+			result.Error = fmt.Sprintf("expected CNAME %s, got %s", server.ExpectCNAME, cname)
+			return result
+		}
+	}
 
-		fmt.Printf("%s [%s] %s:%d - %s (%dms)",
-			status, result.Status, result.Server.Host, result.Server.Port,
-			result.Server.Name, result.ResponseTime)
-		
-		if result.Error != "" {
-			fmt.Printf(" - Error: %s", result.Error)
+	if server.MinTTL != nil || server.MaxTTL != nil {
+		ttl, err := lookupTTL(ctx, server)
+		result.ResponseTime = time.Since(start).Milliseconds()
+		if err != nil {
+			setFailure(&result, err)
+			return result
+		}
+		result.TTL = &ttl
+		if server.MinTTL != nil && int64(ttl) < int64(*server.MinTTL) ||
+			server.MaxTTL != nil && int64(ttl) > int64(*server.MaxTTL) {
+			result.Status = "DEGRADED"
+			result.ErrorCategory = "dns ttl"
+			result.Error = fmt.Sprintf("dns ttl: %ds outside %s", ttl, ttlRange(server))
+			return result
 		}
-		fmt.Println()
 	}
 
-	fmt.Printf("\nSummary: %d UP, %d DOWN\n", upCount, downCount)
+	result.ResponseTime = time.Since(start).Milliseconds()
+	result.Status = "UP"
+	return result
 }
 
-func (m *Monitor) StartContinuousMonitoring(interval time.Duration) {
-	ticker := time.NewTicker(interval)
-	defer ticker.Stop()
-
-	fmt.Printf("Starting continuous monitoring (interval: %v)\n", interval)
-	fmt.Println("Press Ctrl+C to stop...")
-
-	for {
-		select {
-		case <-ticker.C:
-			fmt.Printf("\n--- Health Check at %s ---\n", time.Now().Format("15:04:05"))
-			m.RunCheck()
+// containsIP reports whether want matches one of addrs, comparing parsed
+// IPs so that equivalent IPv6 spellings are treated as equal.
+func containsIP(addrs []string, want string) bool {
+	wantIP := net.ParseIP(want)
+	for _, addr := range addrs {
+		if wantIP != nil {
+			if ip := net.ParseIP(addr); ip != nil && ip.Equal(wantIP) {
+				return true
+			}
+		} else if addr == want {
+			return true
 		}
 	}
+	return false
 }
 
-func (m *Monitor) GenerateReport(filename string) error {
-	// Run a single check
-	m.RunCheck()
-	
-	// Collect results for report
-	var results []HealthResult
-	for _, server := range m.servers {
-		m.wg.Add(1)
-		go m.checkServer(server)
+// check runs the check for a single server, trying each of its fallback
+// hosts in order when it has any.
+func (m *Monitor) check(ctx context.Context, server ServerConfig) HealthResult {
+	if m.isServerPaused(server.Name) {
+		return HealthResult{
+			Server:    server,
+			Status:    "PAUSED",
+			Timestamp: time.Now(),
+		}
+	}
+
+	if server.hours != nil && !server.hours.active(time.Now(), m.location) {
+		return skippedResult(server, "skipped: outside schedule "+server.Schedule)
+	}
+	return m.checkWithRetries(ctx, server)
+}
+
+// attemptCheck makes one attempt at a server's check.
+func (m *Monitor) attemptCheck(ctx context.Context, server ServerConfig) HealthResult {
+	if len(server.Checks) > 0 {
+		return m.checkComposite(ctx, server)
+	}
+	if len(server.Hosts) == 0 {
+		return m.checkProtocol(ctx, server)
+	}
+
+	start := time.Now()
+	var errs []string
+	var result HealthResult
+	for _, host := range server.Hosts {
+		attempt := server
+		attempt.Host = host
+		result = m.checkProtocol(ctx, attempt)
+		result.Server = server
+		if result.up() {
+			result.AnsweredBy = host
+			return result
+		}
+		errs = append(errs, host+": "+result.Error)
+	}
+
+	result.ResponseTime = time.Since(start).Milliseconds()
+	result.Error = "all hosts failed: " + strings.Join(errs, "; ")
+	return result
+}
+
+// checkProtocol runs the protocol-specific check against server.Host, once
+// or as several probes (see sampleProtocol).
+func (m *Monitor) checkProtocol(ctx context.Context, server ServerConfig) HealthResult {
+	result := m.sampleProtocol(ctx, server)
+
+	result.CheckedIP = server.dialIP
+	if result.Status == "UP" && server.BaselineMs > 0 {
+		result.Anomaly = result.ResponseTime > anomalyFactor*int64(server.BaselineMs)
+	}
+	if result.Status == "UP" && server.SoftTimeoutMs > 0 && result.ResponseTime > int64(server.SoftTimeoutMs) {
+		result.Status = "DEGRADED"
+		result.ErrorCategory = "soft timeout"
+		result.Error = fmt.Sprintf("soft timeout: took %dms, over the %dms soft timeout",
+			result.ResponseTime, server.SoftTimeoutMs)
+	}
+	return result
+}
+
+// probeProtocol runs a single protocol-specific probe.
+func (m *Monitor) probeProtocol(ctx context.Context, server ServerConfig) HealthResult {
+	var result HealthResult
+
+	if checker, ok := m.checkers[server.Protocol]; ok {
+		result = checker.Check(ctx, server)
+	} else {
+		result = HealthResult{
+			Server:    server,
+			Status:    "DOWN",
+			Timestamp: time.Now(),
+			Error:     "unsupported protocol: " + server.Protocol,
+		}
+	}
+	return result
+}
+
+// launchChecks checks servers concurrently and streams their results. Each
+// concurrency pool (see ServerConfig.Pool) has its own launcher and limit, so
+// slow checks filling one pool never delay servers in another. Every round
+// owns its channel and WaitGroup, and the channel is closed exactly once,
+// after all checks have sent; consumers can therefore simply range over it.
+//
+// aliases, from dedupeServers, maps a server name to the duplicate entries
+// that share its result; it may be nil. A checkTimeout above 0 bounds each
+// server's checks, including retries and per-address targets.
+func (m *Monitor) launchChecks(ctx context.Context, servers []ServerConfig, aliases map[string][]ServerConfig,
+	checkTimeout time.Duration) <-chan HealthResult {
+	results := make(chan HealthResult, len(servers))
+	deps := newRoundDeps(servers, aliases)
+
+	// run checks one server (and its -dedupe aliases) and tells dependents
+	// how it went
+	run := func(server ServerConfig) {
+		ctx := ctx
+		if checkTimeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, checkTimeout)
+			defer cancel()
+		}
+		up := false
+		for _, target := range m.targets(ctx, server) {
+			result := m.check(ctx, target)
+			if checkTimeout > 0 {
+				result.BudgetMs = checkTimeout.Milliseconds()
+			}
+			up = up || result.up()
+			results <- result
+			for _, alias := range aliases[server.Name] {
+				results <- aliasResult(result, server, alias)
+			}
+		}
+		deps.finish(server.Name, up)
+		for _, alias := range aliases[server.Name] {
+			deps.finish(alias.Name, up)
+		}
+	}
+
+	// skip reports a dependent, and its aliases, as SKIPPED without a check
+	skip := func(server ServerConfig, reason string) {
+		results <- skippedResult(server, reason)
+		deps.finish(server.Name, false)
+		for _, alias := range aliases[server.Name] {
+			results <- skippedResult(alias, reason)
+			deps.finish(alias.Name, false)
+		}
 	}
 
 	go func() {
-		m.wg.Wait()
-		close(m.results)
+		var checks, launchers sync.WaitGroup
+		defer close(results)
+
+		for _, pool := range groupByPool(servers) {
+			launchers.Add(1)
+			go func(pool []ServerConfig) {
+				defer launchers.Done()
+
+				sem := make(chan struct{}, m.poolLimit(pool[0].Pool))
+				for _, server := range pool {
+					if server.DependsOn != "" {
+						// Dependents wait for their parent outside the pool's
+						// slots, so a parent queued behind them can still run
+						checks.Add(1)
+						go func(server ServerConfig) {
+							defer checks.Done()
+							if reason := deps.skipReason(ctx, server); reason != "" {
+								skip(server, reason)
+								return
+							}
+							select {
+							case sem <- struct{}{}:
+							case <-ctx.Done():
+								return
+							}
+							defer func() { <-sem }()
+							run(server)
+						}(server)
+						continue
+					}
+
+					select {
+					case sem <- struct{}{}:
+					case <-ctx.Done():
+						return
+					}
+					checks.Add(1)
+					go func(server ServerConfig) {
+						defer checks.Done()
+						defer func() { <-sem }()
+						run(server)
+					}(server)
+				}
+			}(pool)
+		}
+
+		launchers.Wait()
+		checks.Wait()
 	}()
 
-	for result := range m.results {
+	return results
+}
+
+func (m *Monitor) RunCheck() []HealthResult {
+	return m.runRound(m.currentServers())
+}
+
+// logResult prints a result and sends it to syslog.
+func (m *Monitor) logResult(result HealthResult) {
+	m.printResult(result)
+	if m.syslog != nil {
+		m.syslog.LogResult(result)
+	}
+}
+
+// runRound checks the given servers once, printing each result and a summary.
+func (m *Monitor) runRound(all []ServerConfig) []HealthResult {
+	servers := all
+	var aliases map[string][]ServerConfig
+	if m.dedupe {
+		servers, aliases = dedupeServers(all)
+	}
+	if len(servers) < len(all) {
+		fmt.Fprintf(m.out, "Checking %d servers (%d unique targets)...\n", len(all), len(servers))
+	} else {
+		fmt.Fprintf(m.out, "Checking %d servers...\n", len(all))
+	}
+
+	var checkTimeout time.Duration
+	if m.timeoutBudget > 0 {
+		checkTimeout = m.budgetPerCheck(servers)
+		fmt.Fprintf(m.out, "Timeout budget %s: up to %d waves of checks, %s per check\n",
+			m.timeoutBudget, m.budgetWaves(servers), checkTimeout)
+	}
+
+	// Cancelled on return, or as soon as a server is DOWN with -fail-fast
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Collect and display results; they are streamed as they arrive unless
+	// they have to be buffered for -sort, or for -fail-fast, which reports
+	// only the failure that stopped the round
+	stream := m.sortBy == "" && !m.failFast
+	var results []HealthResult
+	var upCount, degradedCount, downCount, pausedCount, skippedCount int
+	failed := false
+	for result := range m.launchChecks(ctx, servers, aliases, checkTimeout) {
+		if failed {
+			// Drain the checks cancelled by -fail-fast
+			continue
+		}
+		result.Annotations = result.Server.Annotations
+		if m.baseline != nil {
+			result.Regression = m.regression(result)
+		}
 		results = append(results, result)
+		switch result.Status {
+		case "DOWN":
+			downCount++
+		case "PAUSED":
+			pausedCount++
+		case "SKIPPED":
+			skippedCount++
+		case "DEGRADED":
+			degradedCount++
+		default:
+			upCount++
+		}
+
+		if stream {
+			m.logResult(result)
+		}
+
+		if m.failFast && result.Status == "DOWN" {
+			fmt.Fprintln(m.out, "Fail-fast: stopping remaining checks")
+			failed = true
+			cancel()
+			results = []HealthResult{result}
+			upCount, degradedCount, downCount, pausedCount, skippedCount = 0, 0, 1, 0, 0
+		}
+	}
+
+	if !stream {
+		if m.sortBy != "" {
+			sortResults(results, m.sortBy)
+		}
+		for _, result := range results {
+			m.logResult(result)
+		}
+	}
+
+	fmt.Fprintf(m.out, "\nSummary: %d %s, %d %s", upCount, m.label("UP"), downCount, m.label("DOWN"))
+	if degradedCount > 0 {
+		fmt.Fprintf(m.out, ", %d %s", degradedCount, m.label("DEGRADED"))
+	}
+	if pausedCount > 0 {
+		fmt.Fprintf(m.out, ", %d %s", pausedCount, m.label("PAUSED"))
+	}
+	if skippedCount > 0 {
+		fmt.Fprintf(m.out, ", %d %s", skippedCount, m.label("SKIPPED"))
+	}
+	fmt.Fprintln(m.out)
+	if m.timeoutBudget > 0 {
+		budget := m.roundBudget(results)
+		fmt.Fprintf(m.out, "Checks took %dms of the %dms timeout budget\n", budget.ElapsedMs, budget.BudgetMs)
+	}
+	m.warnDNSOutage(results)
+	m.warnFDLimit(results)
+
+	m.record(results)
+	m.publishMetrics(results)
+	return results
+}
+
+func (m *Monitor) printResult(result HealthResult) {
+	fmt.Fprintf(m.out, "%s %s %s - %s (%dms)",
+		m.colorize(result.Status, m.icon(result.Status)),
+		m.colorize(result.Status, "["+m.label(result.Status)+"]"),
+		result.address(), result.Server.Name, result.ResponseTime)
+
+	if result.Timing != nil {
+		fmt.Fprintf(m.out, " [%s]", result.Timing)
+	}
+	if result.Probes != nil {
+		fmt.Fprintf(m.out, " [%d/%d probes ok, min %dms, max %dms]",
+			result.Probes.Succeeded, result.Probes.Count, result.Probes.MinMs, result.Probes.MaxMs)
+	}
+	if result.Attempts > 1 {
+		fmt.Fprintf(m.out, " [%d attempts, %dms retrying]", result.Attempts, result.RetryMs)
+	}
+	if result.Anomaly {
+		fmt.Fprintf(m.out, " - Anomaly: %.1fx the %dms baseline",
+			float64(result.ResponseTime)/float64(result.Server.BaselineMs), result.Server.BaselineMs)
+	}
+	if result.Error != "" {
+		fmt.Fprintf(m.out, " - Error: %s", result.Error)
+	}
+	if result.Regression != "" {
+		fmt.Fprintf(m.out, " - Regression: %s", result.Regression)
+	}
+	fmt.Fprintln(m.out)
+}
+
+// sortKeys are the orders accepted by -sort.
+var sortKeys = []string{"response_time", "name", "status"}
+
+// sortResults orders results for -sort: slowest first, by name, or with
+// DOWN servers first. Ties are broken by name so the order is stable.
+func sortResults(results []HealthResult, by string) {
+	sort.SliceStable(results, func(i, j int) bool {
+		a, b := results[i], results[j]
+		switch by {
+		case "response_time":
+			if a.ResponseTime != b.ResponseTime {
+				return a.ResponseTime > b.ResponseTime
+			}
+		case "status":
+			if a.Status != b.Status {
+				return a.Status == "DOWN"
+			}
+		}
+		return a.Server.Name < b.Server.Name
+	})
+}
+
+// formatTime renders t for console output using -time-format and -timezone.
+func (m *Monitor) formatTime(t time.Time) string {
+	return t.In(m.location).Format(m.timeFormat)
+}
+
+// runContinuousRound checks one schedule group. Rounds of different groups
+// take turns so their output doesn't interleave.
+func (m *Monitor) runContinuousRound(group *scheduleGroup) {
+	m.roundMu.Lock()
+	defer m.roundMu.Unlock()
+
+	if m.isPaused() {
+		fmt.Fprintf(m.out, "\n--- Monitoring paused at %s, skipping round ---\n", m.formatTime(time.Now()))
+		return
+	}
+	start := time.Now()
+	if group.name != "" {
+		fmt.Fprintf(m.out, "\n--- Health Check at %s (%s, every %v) ---\n", m.formatTime(start), group.name, group.interval)
+	} else {
+		fmt.Fprintf(m.out, "\n--- Health Check at %s ---\n", m.formatTime(start))
+	}
+	if m.preHook != "" {
+		m.runHook("pre-hook", m.preHook, nil, nil)
+	}
+	results := m.runRound(group.servers)
+	m.updateStates(results)
+	m.observeErrorRate(results)
+	if m.postHook != "" {
+		m.runPostHook(results)
 	}
+	m.printHeartbeat()
+	m.warnSlowRound(time.Since(start), group.interval)
+}
+
+// clampInterval enforces -min-interval so a typo like "-interval 10ms"
+// doesn't hammer every server.
+func (m *Monitor) clampInterval(interval time.Duration) time.Duration {
+	if interval < m.minInterval {
+		fmt.Fprintf(m.out, "Warning: interval %v is below the minimum %v; using %v (see -min-interval)\n",
+			interval, m.minInterval, m.minInterval)
+		return m.minInterval
+	}
+	return interval
+}
+
+// warnSlowRound warns, once, when a round takes longer than the interval,
+// since the next round is then due before this one finished. Rounds without
+// an interval (-watch-file) are never slow.
+func (m *Monitor) warnSlowRound(elapsed, interval time.Duration) {
+	if interval <= 0 || elapsed <= interval || m.slowRoundWarned {
+		return
+	}
+	m.slowRoundWarned = true
+	fmt.Fprintf(m.out, "Warning: check round took %v, longer than the %v interval; consider a longer -interval\n",
+		elapsed.Round(time.Millisecond), interval)
+}
+
+// StartContinuousMonitoring checks the servers every interval, or on their
+// protocol's -interval-<protocol>, until interrupted. It returns an error if
+// the -fail-on-error-rate watchdog trips.
+func (m *Monitor) StartContinuousMonitoring(interval time.Duration) error {
+	groups := m.scheduleGroups(interval)
+
+	fmt.Fprintf(m.out, "Starting continuous monitoring (interval: %s)\n", describeSchedule(groups))
+	if m.configWatch != "" {
+		fmt.Fprintf(m.out, "Reloading %s when it changes or on SIGHUP\n", m.configWatch)
+	}
+	fmt.Fprintln(m.out, "Press Ctrl+C to stop...")
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	var reloads chan struct{}
+	if m.configWatch != "" {
+		reloads = make(chan struct{})
+		stop := make(chan struct{})
+		defer close(stop)
+		go m.watchConfig(m.configWatch, reloads, stop)
+	}
+
+	var tripped chan error
+	if m.watchdog != nil {
+		tripped = m.watchdog.tripped
+	}
+
+	done := m.runSchedule(groups)
+	for {
+		select {
+		case <-reloads:
+			if m.reloadConfig(m.configWatch) {
+				// Restart the tickers, as servers may have moved between
+				// intervals; the new servers are checked right away
+				close(done)
+				done = m.runSchedule(m.scheduleGroups(interval))
+			}
+		case err := <-tripped:
+			close(done)
+			fmt.Fprintf(m.out, "\nStopping continuous monitoring: %v\n", err)
+			m.saveStateFile()
+			m.printSessionSummary(m.out)
+			return err
+		case <-signals:
+			close(done)
+			fmt.Fprintln(m.out, "\nStopping continuous monitoring")
+			m.saveStateFile()
+			m.printSessionSummary(m.out)
+			return nil
+		}
+	}
+}
+
+// runSchedule starts a ticker per group that runs its rounds until the
+// returned channel is closed.
+func (m *Monitor) runSchedule(groups []*scheduleGroup) chan struct{} {
+	done := make(chan struct{})
+	for _, group := range groups {
+		// Check right away rather than leaving a silent gap until the first tick
+		if !m.waitFirstTick {
+			m.startRound(group)
+		}
+		go func(group *scheduleGroup) {
+			ticker := time.NewTicker(group.interval)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ticker.C:
+					m.startRound(group)
+				case <-done:
+					return
+				}
+			}
+		}(group)
+	}
+	return done
+}
+
+// saveStateFile writes the -state file, if one is configured.
+func (m *Monitor) saveStateFile() {
+	if m.stateFile == "" {
+		return
+	}
+	if err := m.SaveState(m.stateFile); err != nil {
+		fmt.Fprintf(m.out, "Warning: %v\n", err)
+		return
+	}
+	fmt.Fprintf(m.out, "State saved to %s\n", m.stateFile)
+}
+
+// startRound runs a continuous round in the background so ticks stay on
+// schedule, skipping the tick instead if the previous round is still
+// running; slow networks would otherwise stack up rounds of goroutines.
+func (m *Monitor) startRound(group *scheduleGroup) {
+	if !group.running.CompareAndSwap(false, true) {
+		fmt.Fprintf(m.out, "\nWarning: previous round still running at %s, skipping this round\n",
+			m.formatTime(time.Now()))
+		return
+	}
+	go func() {
+		defer group.running.Store(false)
+		m.runContinuousRound(group)
+	}()
+}
+
+func (m *Monitor) GenerateReport(filename string) error {
+	return m.WriteReport(filename, m.RunCheck())
+}
+
+// WriteReport saves the results of a completed check round as JSON, or as
+// HTML for a .html file.
+func (m *Monitor) WriteReport(filename string, results []HealthResult) error {
+	return m.WriteReports([]string{filename}, results)
+}
+
+// WriteReports renders the results of one round to each file, in the format
+// its extension names (see reportFormat), so several formats never cost
+// several rounds of checks.
+func (m *Monitor) WriteReports(filenames []string, results []HealthResult) error {
+	report := m.newReport(results)
+	report.EffectiveConfig = m.effectiveConfig()
+	for _, filename := range filenames {
+		var data []byte
+		var err error
+		if reportFormat(filename) == "html" {
+			data, err = renderHTMLReport(report)
+		} else {
+			data, err = m.marshalReport(report)
+		}
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(filename, data, 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// marshalReport encodes a report indented for people to read, or on one
+// line with -compact, which is noticeably smaller for large fleets.
+func (m *Monitor) marshalReport(v interface{}) ([]byte, error) {
+	if m.compact {
+		return json.Marshal(v)
+	}
+	return json.MarshalIndent(v, "", "  ")
+}
+
+// Report is the JSON document written by -report and returned by the
+// status server's /check endpoint.
+type Report struct {
+	Timestamp time.Time               `json:"timestamp"`
+	Results   []HealthResult          `json:"results,omitempty"`
+	Groups    map[string]*ReportGroup `json:"groups,omitempty"` // with -group-by, instead of Results
+	Summary   struct {
+		Total    int `json:"total"`
+		Up       int `json:"up"`
+		Down     int `json:"down"`
+		Degraded int `json:"degraded,omitempty"` // UP but slower than soft_timeout_ms
+		Skipped  int `json:"skipped,omitempty"`  // dependents of a server that was not UP
+		Paused   int `json:"paused,omitempty"`   // paused via the status endpoint
+		Omitted  int `json:"omitted,omitempty"`  // results dropped by -max-results
+
+		// Results worse than in the -baseline report
+		Regressions int `json:"regressions,omitempty"`
+
+		// Most checks failed to resolve: likely the monitor's own resolver
+		MonitorDNSFailure bool `json:"monitor_dns_failure,omitempty"`
+
+		// Why servers were DOWN, keyed by error category
+		ErrorsByCategory map[string]int `json:"errors_by_category,omitempty"`
+	} `json:"summary"`
 
-	// Generate JSON report
-	report := struct {
-		Timestamp time.Time      `json:"timestamp"`
-		Results   []HealthResult `json:"results"`
-		Summary   struct {
-			Total int `json:"total"`
-			Up    int `json:"up"`
-			Down  int `json:"down"`
-		} `json:"summary"`
-	}{
-		Timestamp: time.Now(),
-		Results:   results,
+	// How the round fared against -healthcheck-timeout-budget
+	Budget *TimeBudget `json:"budget,omitempty"`
+
+	// The resolved configuration the results were checked with, in -report
+	// files
+	EffectiveConfig *EffectiveConfig `json:"effective_config,omitempty"`
+}
+
+func (m *Monitor) newReport(results []HealthResult) Report {
+	report := Report{
+		Timestamp: time.Now().In(m.location),
+		// A copy, as the results are redacted and re-zoned below while the
+		// caller may still need the originals
+		Results: append([]HealthResult(nil), results...),
 	}
 
-	for _, result := range results {
+	for i, result := range results {
+		report.Results[i].Server = result.Server.redacted()
+		report.Results[i].Timestamp = result.Timestamp.In(m.location)
 		report.Summary.Total++
-		if result.Status == "UP" {
+		switch result.Status {
+		case "UP":
 			report.Summary.Up++
-		} else {
+		case "DEGRADED":
+			report.Summary.Degraded++
+		case "SKIPPED":
+			report.Summary.Skipped++
+		case "PAUSED":
+			report.Summary.Paused++
+		default:
 			report.Summary.Down++
 		}
+
+		if result.Regression != "" {
+			report.Summary.Regressions++
+		}
+		if result.Status == "DOWN" {
+			category := result.ErrorCategory
+			if category == "" {
+				category = "error"
+			}
+			if report.Summary.ErrorsByCategory == nil {
+				report.Summary.ErrorsByCategory = make(map[string]int)
+			}
+			report.Summary.ErrorsByCategory[category]++
+		}
 	}
 
-	data, err := json.MarshalIndent(report, "", "  ")
-	if err != nil {
-		return err
+	_, _, report.Summary.MonitorDNSFailure = dnsOutage(results)
+	if m.timeoutBudget > 0 {
+		report.Budget = m.roundBudget(results)
 	}
 
-	return os.WriteFile(filename, data, 0644)
-}
+	// The summary covers every result; only the list is capped, keeping
+	// the top of the -sort order (DOWN servers first without -sort)
+	if m.maxResults > 0 && len(results) > m.maxResults {
+		by := m.sortBy
+		if by == "" {
+			by = "status"
+		}
+		kept := append([]HealthResult(nil), results...)
+		sortResults(kept, by)
+		report.Results = kept[:m.maxResults]
+		report.Summary.Omitted = len(results) - m.maxResults
+	}
 
-func createSampleConfig() {
-	config := struct {
-		Servers []ServerConfig `json:"servers"`
-	}{
-		Servers: []ServerConfig{
-			{Name: "Google DNS", Host: "8.8.8.8", Port: 53, Protocol: "tcp", Timeout: 5},
-			{Name: "Google", Host: "google.com", Port: 80, Protocol: "http", Timeout: 10},
-			{Name: "GitHub", Host: "github.com", Port: 443, Protocol: "https", Timeout: 10},
-			{Name: "Local SSH", Host: "localhost", Port: 22, Protocol: "tcp", Timeout: 3},
-			{Name: "Local Web", Host: "localhost", Port: 8080, Protocol: "http", Timeout: 5},
-		},
+	if m.groupBy != "" {
+		report.Groups = groupResults(report.Results, m.groupBy)
+		report.Results = nil
 	}
+	return report
+}
+
+func createSampleConfig(filename string) {
+	os.WriteFile(filename, defaultConfig, 0644)
+	fmt.Printf("Created sample configuration: %s\n", filename)
+}
 
-	data, _ := json.MarshalIndent(config, "", "  ")
-	os.WriteFile("servers.json", data, 0644)
-	fmt.Println("Created sample configuration: servers.json")
+// timeLayout maps a few well-known format names to Go layouts; anything else
+// is used as a layout verbatim.
+func timeLayout(name string) string {
+	switch strings.ToLower(name) {
+	case "rfc3339":
+		return time.RFC3339
+	case "rfc3339nano":
+		return time.RFC3339Nano
+	case "datetime":
+		return time.DateTime
+	case "kitchen":
+		return time.Kitchen
+	}
+	return name
 }
 
 func printUsage() {
@@ -269,10 +1678,79 @@ func printUsage() {
 	fmt.Println()
 	fmt.Println("Options:")
 	fmt.Println("  -config <file>     Configuration file (default: servers.json)")
+	fmt.Println("  -target <url>     Check this target once instead of the config, e.g. tcp://8.8.8.8:53 (repeatable)")
+	fmt.Println("  -list-protocols   List the supported protocols and their config fields, then exit")
+	fmt.Println("  -embedded         Use the built-in default servers instead of a config file")
+	fmt.Println("  -create-config    Write a sample config file if the config file is missing")
+	fmt.Println("  -strict-config    Reject unknown fields in the config file")
+	fmt.Println("  -jsonc            Allow comments and trailing commas in any config file, not just .jsonc/.json5")
+	fmt.Println("  -exclude <names>  Don't check these servers (comma-separated, repeatable)")
+	fmt.Println("  -exclude-tag <tags> Don't check servers with any of these tags (comma-separated, repeatable)")
 	fmt.Println("  -once             Run check once and exit")
 	fmt.Println("  -interval <dur>   Continuous monitoring interval (default: 30s)")
-	fmt.Println("  -report <file>    Generate JSON report")
+	fmt.Println("  -interval-<protocol> <dur> Interval for tcp, http, https, dns or kafka servers instead of -interval")
+	fmt.Println("  -min-interval <dur> Shortest -interval allowed; lower values are raised to it (default: 1s)")
+	fmt.Println("  -latency-window <dur> Window for rolling p95 latency alerts (default: 5m)")
+	fmt.Println("  -concurrency <n>  Most checks in flight at once; overrides \"concurrency\" in the config (default: 32 per CPU)")
+	fmt.Println("  -pool <name=n>    Limit the named concurrency pool to n checks (repeatable)")
+	fmt.Println("  -status-addr <a>  Serve /status, /alerts, /metrics, /pause, /resume and /check here (e.g. :8081)")
+	fmt.Println("  -k8s-probe <a>    Serve Kubernetes /livez and /readyz here (e.g. :8082)")
+	fmt.Println("  -ready-tag <tag>  Servers with this tag gate /readyz (default: dependency)")
+	fmt.Println("  -pprof <addr>     Serve net/http/pprof on this address, e.g. localhost:6060 (off by default)")
+	fmt.Println("  -webhook <url>    POST alerts as JSON to this URL, Slack-compatible (repeatable)")
+	fmt.Println("  -syslog <addr>    Also log results and alerts to syslog: local, udp://host:514 or tcp://host:514")
+	fmt.Println("  -alert-template <t> Go text/template for alert messages, or @file")
+	fmt.Println("  -watch-file <path> Run a check round each time the file changes, instead of on a timer")
+	fmt.Println("  -config-watch     Reload -config when it changes or on SIGHUP; an invalid config is rejected and the old one kept")
+	fmt.Println("  -tui              Full-screen live dashboard instead of scrolling output")
+	fmt.Println("  -pre-hook <cmd>   Shell command run before each continuous round")
+	fmt.Println("  -post-hook <cmd>  Shell command run after each round (HM_UP/HM_DOWN/HM_TOTAL, report on stdin)")
+	fmt.Println("  -hook-timeout <d> Time limit for each hook run (default: 30s)")
+	fmt.Println("  -state <file>     Save alert state on shutdown and restore it on startup")
+	fmt.Println("  -startup-delay <d> Wait this long before the first check, for targets starting alongside")
+	fmt.Println("  -grace <dur>      Ignore DOWN results for state changes and alerts this long after start")
+	fmt.Println("  -wait-first       Wait one interval before the first continuous check")
+	fmt.Println("  -report <file>    Generate a report: HTML for .html/.htm files, otherwise JSON (repeatable)")
+	fmt.Println("  -oneline          Run once and print only UP:n DOWN:n for status bars")
+	fmt.Println("  -oneline-names    Like -oneline, also listing the DOWN servers")
+	fmt.Println("  -merge <files...> Combine -report files from several regions into one per-server view")
+	fmt.Println("  -record <file>    Append every round's results to a file for -replay")
+	fmt.Println("  -record-max-size <size> Rotate the -record file before it grows past this, e.g. 10MB (alias: -report-max-size)")
+	fmt.Println("  -record-max-files <n> Rotated -record files to keep (default: 5, alias: -report-max-files)")
+	fmt.Println("  -replay <file>    Replay recorded rounds through the output and alert pipeline")
+	fmt.Println("  -replay-speed <x> Replay x times faster than recorded, 0 for no delay (default: 1)")
+	fmt.Println("  -compact          Write reports as compact JSON instead of indented")
+	fmt.Println("  -group-by <field> Nest report results by group, tag, protocol or pool with per-group counts")
+	fmt.Println("  -max-results <n>  List only the first n results in the report in -sort order (default: DOWN first)")
+	fmt.Println("  -sort <key>       Order output by response_time (slowest first), name or status")
+	fmt.Println("  -time-format <f>  Console timestamp layout or rfc3339/datetime/kitchen (default: 15:04:05)")
+	fmt.Println("  -timezone <tz>    Timezone for console and report timestamps (default: local)")
+	fmt.Println("  -utc              Same as -timezone UTC")
+	fmt.Println("  -seed <n>         Seed for all randomized behavior, to reproduce a run (default: time-based)")
+	fmt.Println("  -max-body-bytes <n> Read at most n bytes of each HTTP response body (default: 1048576)")
+	fmt.Println("  -healthcheck-timeout-budget <d> Fit each round into d (e.g. 30s) by deriving per-check timeouts")
+	fmt.Println("  -cold             Measure every HTTP check from a cold connection and record dns/connect/tls/ttfb times")
+	fmt.Println("  -dedupe           Probe identical targets once and report the result under every name")
+	fmt.Println("  -resolve          Check TCP/HTTP servers once per resolved address family (IPv4/IPv6)")
+	fmt.Println("  -probe-count <n>  Probe each server n times per check and report the average (UP if most succeed)")
+	fmt.Println("  -resolve-all      Check TCP/HTTP servers once per resolved address, e.g. behind round-robin DNS")
+	fmt.Println("  -socks5 <addr>    Tunnel TCP/HTTP checks through a SOCKS5 proxy ([user:pass@]host:port)")
+	fmt.Println("  -explain <name>   Check one server with step-by-step diagnostics and exit")
+	fmt.Println("  -baseline <file>  Flag servers that are DOWN or slower than in this known-good report; exit 1 if any")
+	fmt.Println("  -baseline-slowdown <p%> How much slower than -baseline counts as a regression (default: 50%)")
+	fmt.Println("  -threshold <n|p%> Exit non-zero only if more than n (or p%) servers are DOWN")
+	fmt.Println("  -fail-on-error-rate <n|p%> Continuous mode: exit non-zero once more than n (or p%) servers stay DOWN for -for")
+	fmt.Println("  -for <dur>        How long -fail-on-error-rate must hold before exiting (default: 0, the first round)")
+	fmt.Println("  -warn-only        Always exit 0; health failures are only printed as warnings")
+	fmt.Println("  -fail-fast        Stop at the first DOWN server and exit non-zero")
 	fmt.Println("  -sample           Create sample configuration file")
+	fmt.Println("  -benchmark <dur>  Run check rounds back to back and report checks/sec")
+	fmt.Println("  -benchmark-loopback Benchmark against local listeners instead of the config")
+	fmt.Println("  -selftest         Check local TCP/HTTP listeners to verify the tool works")
+	fmt.Println("  -icons <spec>     Console icons per status, e.g. UP=+,DOWN=x (ASCII terminals)")
+	fmt.Println("  -labels <spec>    Console status labels, e.g. UP=OK,DOWN=FAIL")
+	fmt.Println("  -color            Always color status output")
+	fmt.Println("  -no-color         Never color status output (auto: only on a TTY)")
 	fmt.Println("  -help             Show this help")
 	fmt.Println()
 	fmt.Println("Examples:")
@@ -288,7 +1766,71 @@ func main() {
 	configFile := "servers.json"
 	runOnce := false
 	interval := 30 * time.Second
-	reportFile := ""
+	var reportFiles []string
+	colorMode := "auto"
+	waitFirst := false
+	failFast := false
+	timeFormat := ""
+	timezone := ""
+	socksProxy := ""
+	explainName := ""
+	strictConfig := false
+	jsonc := false
+	selfTest := false
+	latencyWindow := time.Duration(0)
+	var threshold *downThreshold
+	var errorRate *downThreshold
+	errorRateWindow := time.Duration(0)
+	tui := false
+	watchFile := ""
+	configWatch := false
+	sortBy := ""
+	perFamily := false
+	allAddrs := false
+	probeCount := 1
+	statusAddr := ""
+	probeAddr := ""
+	readyTag := defaultReadyTag
+	pprofAddr := ""
+	poolLimits := make(map[string]int)
+	oneline := false
+	useEmbedded := false
+	createConfig := false
+	dedupe := false
+	cold := false
+	alertTemplate := ""
+	benchmark := time.Duration(0)
+	benchmarkLoopback := false
+	var webhooks []string
+	var excludeNames, excludeTags map[string]bool
+	concurrency := 0
+	var targets []string
+	onelineNames := false
+	minInterval := time.Duration(-1)
+	startupDelay := time.Duration(0)
+	stateFile := ""
+	warnOnly := false
+	preHook, postHook := "", ""
+	hookTimeout := time.Duration(0)
+	grace := time.Duration(0)
+	var icons, labels map[string]string
+	maxResults := 0
+	baselineFile := ""
+	protocolIntervals := make(map[string]time.Duration)
+	baselineSlowdown := float64(defaultBaselineSlowdown)
+	groupBy := ""
+	compact := false
+	syslogSpec := ""
+	maxBodyBytes := int64(0)
+	var timeoutBudget time.Duration
+	seed := int64(0)
+	seeded := false
+	var mergeFiles []string
+	recordFile := ""
+	recordMaxSize := int64(0)
+	recordMaxFiles := defaultRecordMaxFiles
+	replayFile := ""
+	replaySpeed := 1.0
 
 	// Simple argument parsing
 	for i := 0; i < len(args); i++ {
@@ -297,7 +1839,10 @@ func main() {
 			printUsage()
 			return
 		case "-sample":
-			createSampleConfig()
+			createSampleConfig("servers.json")
+			return
+		case "-list-protocols":
+			NewMonitor().printProtocols(os.Stdout)
 			return
 		case "-config":
 			if i+1 < len(args) {
@@ -313,39 +1858,713 @@ func main() {
 				}
 				i++
 			}
+		case "-interval-tcp", "-interval-http", "-interval-https", "-interval-dns", "-interval-kafka":
+			if i+1 < len(args) {
+				if d, err := time.ParseDuration(args[i+1]); err == nil {
+					protocolIntervals[strings.TrimPrefix(args[i], "-interval-")] = d
+				}
+				i++
+			}
+		case "-state":
+			if i+1 < len(args) {
+				stateFile = args[i+1]
+				i++
+			}
+		case "-startup-delay":
+			if i+1 < len(args) {
+				if d, err := time.ParseDuration(args[i+1]); err == nil {
+					startupDelay = d
+				}
+				i++
+			}
+		case "-grace":
+			if i+1 < len(args) {
+				if d, err := time.ParseDuration(args[i+1]); err == nil {
+					grace = d
+				}
+				i++
+			}
+		case "-min-interval":
+			if i+1 < len(args) {
+				if d, err := time.ParseDuration(args[i+1]); err == nil && d >= 0 {
+					minInterval = d
+				}
+				i++
+			}
 		case "-report":
 			if i+1 < len(args) {
-				reportFile = args[i+1]
+				reportFiles = append(reportFiles, args[i+1])
+				i++
+			}
+		case "-color":
+			colorMode = "always"
+		case "-no-color":
+			colorMode = "never"
+		case "-wait-first":
+			waitFirst = true
+		case "-pre-hook":
+			if i+1 < len(args) {
+				preHook = args[i+1]
+				i++
+			}
+		case "-post-hook":
+			if i+1 < len(args) {
+				postHook = args[i+1]
+				i++
+			}
+		case "-hook-timeout":
+			if i+1 < len(args) {
+				if d, err := time.ParseDuration(args[i+1]); err == nil && d > 0 {
+					hookTimeout = d
+				}
+				i++
+			}
+		case "-warn-only":
+			warnOnly = true
+		case "-fail-fast":
+			failFast = true
+		case "-time-format":
+			if i+1 < len(args) {
+				timeFormat = args[i+1]
+				i++
+			}
+		case "-timezone":
+			if i+1 < len(args) {
+				timezone = args[i+1]
+				i++
+			}
+		case "-utc":
+			timezone = "UTC"
+		case "-latency-window":
+			if i+1 < len(args) {
+				if d, err := time.ParseDuration(args[i+1]); err == nil {
+					latencyWindow = d
+				}
+				i++
+			}
+		case "-threshold":
+			if i+1 < len(args) {
+				t, err := parseThreshold(args[i+1])
+				if err != nil {
+					log.Fatalf("Error: %v", err)
+				}
+				threshold = &t
+				i++
+			}
+		case "-fail-on-error-rate":
+			if i+1 < len(args) {
+				t, err := parseThreshold(args[i+1])
+				if err != nil {
+					log.Fatalf("Error: %v", err)
+				}
+				errorRate = &t
+				i++
+			}
+		case "-for":
+			if i+1 < len(args) {
+				if d, err := time.ParseDuration(args[i+1]); err == nil {
+					errorRateWindow = d
+				}
+				i++
+			}
+		case "-seed":
+			if i+1 < len(args) {
+				n, err := strconv.ParseInt(args[i+1], 10, 64)
+				if err != nil {
+					log.Fatalf("Invalid -seed %q: want an integer", args[i+1])
+				}
+				seed, seeded = n, true
+				i++
+			}
+		case "-max-body-bytes":
+			if i+1 < len(args) {
+				n, err := strconv.ParseInt(args[i+1], 10, 64)
+				if err != nil || n < 1 {
+					log.Fatalf("Invalid -max-body-bytes %q: want a positive number of bytes", args[i+1])
+				}
+				maxBodyBytes = n
+				i++
+			}
+		case "-healthcheck-timeout-budget":
+			if i+1 < len(args) {
+				d, err := time.ParseDuration(args[i+1])
+				if err != nil || d <= 0 {
+					log.Fatalf("Invalid -healthcheck-timeout-budget %q: want a positive duration such as 30s", args[i+1])
+				}
+				timeoutBudget = d
+				i++
+			}
+		case "-syslog":
+			if i+1 < len(args) {
+				syslogSpec = args[i+1]
+				i++
+			}
+		case "-compact":
+			compact = true
+		case "-group-by":
+			if i+1 < len(args) {
+				key, err := parseGroupBy(args[i+1])
+				if err != nil {
+					log.Fatalf("Error: %v", err)
+				}
+				groupBy = key
+				i++
+			}
+		case "-max-results":
+			if i+1 < len(args) {
+				n, err := strconv.Atoi(args[i+1])
+				if err != nil || n < 1 {
+					log.Fatalf("Invalid -max-results %q: want a positive integer", args[i+1])
+				}
+				maxResults = n
+				i++
+			}
+		case "-baseline":
+			if i+1 < len(args) {
+				baselineFile = args[i+1]
+				i++
+			}
+		case "-baseline-slowdown":
+			if i+1 < len(args) {
+				p, err := strconv.ParseFloat(strings.TrimSuffix(args[i+1], "%"), 64)
+				if err != nil || p < 0 {
+					log.Fatalf("Invalid -baseline-slowdown %q: want a percentage such as 50%%", args[i+1])
+				}
+				baselineSlowdown = p
+				i++
+			}
+		case "-icons", "-labels":
+			if i+1 < len(args) {
+				overrides, err := parseStatusStrings(args[i], args[i+1])
+				if err != nil {
+					log.Fatalf("Error: %v", err)
+				}
+				if args[i] == "-icons" {
+					icons = overrides
+				} else {
+					labels = overrides
+				}
+				i++
+			}
+		case "-sort":
+			if i+1 < len(args) {
+				sortBy = args[i+1]
+				if !slices.Contains(sortKeys, sortBy) {
+					log.Fatalf("Invalid sort %q: expected one of %s", sortBy, strings.Join(sortKeys, ", "))
+				}
+				i++
+			}
+		case "-target":
+			if i+1 < len(args) {
+				targets = append(targets, args[i+1])
+				i++
+			}
+		case "-concurrency":
+			if i+1 < len(args) {
+				n, err := strconv.Atoi(args[i+1])
+				if err != nil || n < 1 {
+					log.Fatalf("Invalid -concurrency %q: must be a positive integer", args[i+1])
+				}
+				concurrency = n
+				i++
+			}
+		case "-pool":
+			if i+1 < len(args) {
+				name, limit, err := parsePoolLimit(args[i+1])
+				if err != nil {
+					log.Fatalf("Error: %v", err)
+				}
+				poolLimits[name] = limit
+				i++
+			}
+		case "-alert-template":
+			if i+1 < len(args) {
+				alertTemplate = args[i+1]
+				i++
+			}
+		case "-exclude":
+			if i+1 < len(args) {
+				excludeNames = addExcludes(excludeNames, args[i+1])
+				i++
+			}
+		case "-exclude-tag":
+			if i+1 < len(args) {
+				excludeTags = addExcludes(excludeTags, args[i+1])
+				i++
+			}
+		case "-webhook":
+			if i+1 < len(args) {
+				webhooks = append(webhooks, args[i+1])
+				i++
+			}
+		case "-benchmark":
+			if i+1 < len(args) {
+				if d, err := time.ParseDuration(args[i+1]); err == nil {
+					benchmark = d
+				}
+				i++
+			}
+		case "-benchmark-loopback":
+			benchmarkLoopback = true
+		case "-cold":
+			cold = true
+		case "-dedupe":
+			dedupe = true
+		case "-embedded":
+			useEmbedded = true
+		case "-create-config":
+			createConfig = true
+		case "-oneline":
+			oneline = true
+		case "-oneline-names":
+			oneline = true
+			onelineNames = true
+		case "-pprof":
+			if i+1 < len(args) {
+				pprofAddr = args[i+1]
+				i++
+			}
+		case "-merge":
+			// Every following argument up to the next option is a report
+			for i+1 < len(args) && !strings.HasPrefix(args[i+1], "-") {
+				mergeFiles = append(mergeFiles, args[i+1])
+				i++
+			}
+			if len(mergeFiles) == 0 {
+				log.Fatalf("-merge needs at least one report file")
+			}
+		case "-record":
+			if i+1 < len(args) {
+				recordFile = args[i+1]
+				i++
+			}
+		case "-record-max-size", "-report-max-size":
+			// -report writes a single round; -record is the appending
+			// report mode, so the -report-* names are accepted as well
+			if i+1 < len(args) {
+				n, err := parseSize(args[i+1])
+				if err != nil {
+					log.Fatalf("Invalid %s %q: %v", args[i], args[i+1], err)
+				}
+				recordMaxSize = n
+				i++
+			}
+		case "-record-max-files", "-report-max-files":
+			if i+1 < len(args) {
+				n, err := strconv.Atoi(args[i+1])
+				if err != nil || n < 1 {
+					log.Fatalf("Invalid %s %q: want a positive integer", args[i], args[i+1])
+				}
+				recordMaxFiles = n
+				i++
+			}
+		case "-replay":
+			if i+1 < len(args) {
+				replayFile = args[i+1]
+				i++
+			}
+		case "-replay-speed":
+			if i+1 < len(args) {
+				speed, err := strconv.ParseFloat(args[i+1], 64)
+				if err != nil || speed < 0 {
+					log.Fatalf("Invalid -replay-speed %q: want a non-negative number", args[i+1])
+				}
+				replaySpeed = speed
+				i++
+			}
+		case "-k8s-probe":
+			if i+1 < len(args) {
+				probeAddr = args[i+1]
+				i++
+			}
+		case "-ready-tag":
+			if i+1 < len(args) {
+				readyTag = args[i+1]
+				i++
+			}
+		case "-status-addr":
+			if i+1 < len(args) {
+				statusAddr = args[i+1]
+				i++
+			}
+		case "-resolve":
+			perFamily = true
+		case "-resolve-all":
+			allAddrs = true
+		case "-probe-count":
+			if i+1 < len(args) {
+				n, err := strconv.Atoi(args[i+1])
+				if err != nil || n < 1 {
+					log.Fatalf("Invalid -probe-count %q: want a positive integer", args[i+1])
+				}
+				probeCount = n
+				i++
+			}
+		case "-watch-file":
+			if i+1 < len(args) {
+				watchFile = args[i+1]
+				i++
+			}
+		case "-config-watch":
+			configWatch = true
+		case "-tui":
+			tui = true
+		case "-selftest":
+			selfTest = true
+		case "-strict-config":
+			strictConfig = true
+		case "-jsonc":
+			jsonc = true
+		case "-explain":
+			if i+1 < len(args) {
+				explainName = args[i+1]
+				i++
+			}
+		case "-socks5":
+			if i+1 < len(args) {
+				socksProxy = args[i+1]
 				i++
 			}
 		}
 	}
 
+	if seeded {
+		seedRandom(seed)
+	}
+
 	monitor := NewMonitor()
+	monitor.useColor = colorEnabled(colorMode)
+	monitor.icons = icons
+	monitor.labels = labels
+	monitor.waitFirstTick = waitFirst
+	monitor.failFast = failFast
+	monitor.strictConfig = strictConfig
+	monitor.jsonc = jsonc
+	monitor.sortBy = sortBy
+	monitor.maxResults = maxResults
+	monitor.baselineSlowdown = baselineSlowdown
+	monitor.protocolIntervals = protocolIntervals
+	monitor.groupBy = groupBy
+	monitor.compact = compact
+	if maxBodyBytes > 0 {
+		monitor.maxBodyBytes = maxBodyBytes
+	}
+	monitor.timeoutBudget = timeoutBudget
+	monitor.perFamily = perFamily
+	monitor.allAddrs = allAddrs
+	monitor.probes = probeCount
+	monitor.dedupe = dedupe
+	if concurrency > 0 {
+		monitor.maxConcurrency = concurrency
+	}
+	monitor.excludeNames = excludeNames
+	if errorRate != nil {
+		// Only the scrolling continuous mode's rounds feed the watchdog
+		if tui || watchFile != "" {
+			log.Fatalf("-fail-on-error-rate can't be used with -tui or -watch-file")
+		}
+		monitor.watchdog = newErrorRateWatchdog(*errorRate, errorRateWindow)
+	}
+	monitor.excludeTags = excludeTags
+	monitor.cold = cold
+	if alertTemplate != "" {
+		tmpl, err := parseAlertTemplate(alertTemplate)
+		if err != nil {
+			fmt.Printf("Warning: %v; using the default alert template\n", err)
+		} else {
+			monitor.alertTemplate = tmpl
+		}
+	}
+	for _, url := range webhooks {
+		monitor.notifiers = append(monitor.notifiers, newWebhookNotifier(url))
+	}
+	if syslogSpec != "" {
+		sink, err := newSyslogSink(syslogSpec)
+		if err != nil {
+			log.Fatalf("Error: %v", err)
+		}
+		monitor.syslog = sink
+		monitor.notifiers = append(monitor.notifiers, sink)
+	}
+	monitor.poolLimits = poolLimits
+	if latencyWindow > 0 {
+		monitor.latencyWindow = latencyWindow
+	}
+	if minInterval >= 0 {
+		monitor.minInterval = minInterval
+	}
+	monitor.preHook = preHook
+	monitor.postHook = postHook
+	if hookTimeout > 0 {
+		monitor.hookTimeout = hookTimeout
+	}
+	if timeFormat != "" {
+		monitor.timeFormat = timeLayout(timeFormat)
+	}
+	if timezone != "" {
+		loc, err := time.LoadLocation(timezone)
+		if err != nil {
+			log.Fatalf("Invalid timezone %q: %v", timezone, err)
+		}
+		monitor.location = loc
+	}
+	if socksProxy != "" {
+		dial, err := newSOCKS5Dialer(socksProxy)
+		if err != nil {
+			log.Fatalf("Error configuring proxy: %v", err)
+		}
+		monitor.dial = dial
+	}
+
+	if selfTest {
+		if !monitor.RunSelfTest() {
+			fmt.Println("Self-test FAILED")
+			os.Exit(1)
+		}
+		fmt.Println("Self-test PASSED")
+		return
+	}
+
+	if replayFile != "" {
+		if err := monitor.Replay(replayFile, replaySpeed); err != nil {
+			log.Fatalf("Error: %v", err)
+		}
+		return
+	}
+
+	if len(mergeFiles) > 0 {
+		merged, err := monitor.MergeReports(mergeFiles)
+		if err != nil {
+			log.Fatalf("Error: %v", err)
+		}
+		monitor.printMerged(merged)
+		for _, reportFile := range reportFiles {
+			if reportFormat(reportFile) != "json" {
+				log.Fatalf("Error generating report: merged reports are JSON only, not %s", reportFile)
+			}
+			data, err := monitor.marshalReport(merged)
+			if err != nil {
+				log.Fatalf("Error generating report: %v", err)
+			}
+			if err := os.WriteFile(reportFile, data, 0644); err != nil {
+				log.Fatalf("Error generating report: %v", err)
+			}
+			fmt.Printf("Merged report saved to %s\n", reportFile)
+		}
+		return
+	}
 
-	// Check if config file exists
-	if _, err := os.Stat(configFile); os.IsNotExist(err) {
-		fmt.Printf("Config file '%s' not found. Creating sample...\n", configFile)
-		createSampleConfig()
+	if recordFile != "" {
+		rec, err := newRecorder(recordFile, recordMaxSize, recordMaxFiles)
+		if err != nil {
+			log.Fatalf("Error: %v", err)
+		}
+		defer rec.Close()
+		monitor.recorder = rec
 	}
 
-	if err := monitor.LoadConfig(configFile); err != nil {
+	if benchmark > 0 && benchmarkLoopback {
+		servers, stop, err := startLoopbackTargets()
+		if err != nil {
+			log.Fatalf("Error: %v", err)
+		}
+		defer stop()
+		monitor.servers = servers
+		monitor.RunBenchmark(benchmark)
+		return
+	}
+
+	// Without a config file fall back to the built-in defaults in memory
+	// rather than writing files; -create-config restores the old behavior
+	if _, err := os.Stat(configFile); os.IsNotExist(err) && !useEmbedded && len(targets) == 0 {
+		if createConfig {
+			fmt.Printf("Config file '%s' not found. Creating sample...\n", configFile)
+			createSampleConfig(configFile)
+		} else {
+			if !oneline {
+				fmt.Printf("Config file '%s' not found. Using built-in default servers\n", configFile)
+			}
+			useEmbedded = true
+		}
+	}
+
+	if len(targets) > 0 {
+		// Ad-hoc targets replace the config file entirely
+		configFile = "command-line targets"
+		servers, err := monitor.parseTargets(targets)
+		if err != nil {
+			log.Fatalf("Error: %v", err)
+		}
+		if err := monitor.setServers(servers); err != nil {
+			log.Fatalf("Error: %v", err)
+		}
+		monitor.configSource = configFile
+		// A quick diagnostic, not a monitoring session
+		if len(reportFiles) == 0 && !oneline {
+			runOnce = true
+		}
+	} else if useEmbedded {
+		configFile = "built-in default config"
+		if err := monitor.LoadDefaultConfig(); err != nil {
+			log.Fatalf("Error loading config: %v", err)
+		}
+	} else if err := monitor.LoadConfig(configFile); err != nil {
 		log.Fatalf("Error loading config: %v", err)
 	}
+	// -concurrency, then "concurrency" in the config, then the default
+	if concurrency == 0 && monitor.configConcurrency > 0 {
+		monitor.maxConcurrency = monitor.configConcurrency
+	}
+	if configWatch {
+		if useEmbedded || len(targets) > 0 {
+			log.Fatalf("-config-watch needs a config file")
+		}
+		monitor.configWatch = configFile
+	}
+
+	// -oneline output is consumed by status bars, so it prints nothing else
+	if oneline {
+		monitor.out = io.Discard
+	} else {
+		fmt.Printf("Loaded %d servers from %s\n", len(monitor.servers), configFile)
+		if monitor.disabled > 0 {
+			fmt.Printf("Skipping %d disabled servers\n", monitor.disabled)
+		}
+		if monitor.excluded > 0 {
+			fmt.Printf("Excluding %d servers (-exclude/-exclude-tag)\n", monitor.excluded)
+		}
+		fmt.Printf("Go version: %s, OS: %s, Arch: %s\n",
+			runtime.Version(), runtime.GOOS, runtime.GOARCH)
+		fmt.Printf("CPUs available: %d, max concurrent checks: %d\n",
+			availableCPUs(), monitor.maxConcurrency)
+		fmt.Printf("Random seed: %d (repeat with -seed)\n", randomSeed)
+	}
+
+	if baselineFile != "" {
+		baseline, err := loadBaseline(baselineFile)
+		if err != nil {
+			log.Fatalf("Error loading baseline: %v", err)
+		}
+		monitor.baseline = baseline
+	}
+
+	if stateFile != "" {
+		monitor.stateFile = stateFile
+		restored, err := monitor.LoadState(stateFile)
+		if err != nil {
+			fmt.Printf("Warning: %v; starting with fresh state\n", err)
+		} else if restored > 0 && !oneline {
+			fmt.Printf("Restored state of %d servers from %s\n", restored, stateFile)
+		}
+	}
+
+	if pprofAddr != "" {
+		if err := startPprof(pprofAddr); err != nil {
+			log.Fatalf("Error: %v", err)
+		}
+	}
+
+	if statusAddr != "" {
+		if err := monitor.StartStatusServer(statusAddr); err != nil {
+			log.Fatalf("Error: %v", err)
+		}
+	}
 
-	fmt.Printf("Loaded %d servers from %s\n", len(monitor.servers), configFile)
-	fmt.Printf("Go version: %s, OS: %s, Arch: %s\n", 
-		runtime.Version(), runtime.GOOS, runtime.GOARCH)
+	if probeAddr != "" {
+		if err := monitor.StartProbeServer(probeAddr, readyTag); err != nil {
+			log.Fatalf("Error: %v", err)
+		}
+	}
+
+	if startupDelay > 0 {
+		if !oneline {
+			fmt.Printf("Waiting %v before the first check...\n", startupDelay)
+		}
+		time.Sleep(startupDelay)
+	}
+	if grace > 0 {
+		monitor.graceUntil = time.Now().Add(grace)
+	}
+
+	if explainName != "" {
+		if err := monitor.Explain(explainName); err != nil {
+			log.Fatalf("Error: %v", err)
+		}
+		return
+	}
+
+	if benchmark > 0 {
+		monitor.RunBenchmark(benchmark)
+		return
+	}
 
-	if reportFile != "" {
-		fmt.Printf("Generating report: %s\n", reportFile)
-		if err := monitor.GenerateReport(reportFile); err != nil {
+	var results []HealthResult
+	var tripped error // -fail-on-error-rate stopped continuous monitoring
+	if oneline {
+		results = monitor.RunCheck()
+		fmt.Println(onelineSummary(results, onelineNames))
+	} else if len(reportFiles) > 0 {
+		fmt.Printf("Generating report: %s\n", strings.Join(reportFiles, ", "))
+		results = monitor.RunCheck()
+		if err := monitor.WriteReports(reportFiles, results); err != nil {
 			log.Fatalf("Error generating report: %v", err)
 		}
-		fmt.Printf("Report saved to %s\n", reportFile)
+		fmt.Printf("Report saved to %s\n", strings.Join(reportFiles, ", "))
 	} else if runOnce {
-		monitor.RunCheck()
+		results = monitor.RunCheck()
+	} else if watchFile != "" {
+		monitor.WatchFile(watchFile)
+	} else if tui {
+		monitor.StartTUI(interval)
 	} else {
-		monitor.StartContinuousMonitoring(interval)
+		tripped = monitor.StartContinuousMonitoring(interval)
+	}
+
+	down := countDown(results)
+	failed := false
+	if threshold != nil {
+		exceeded := threshold.exceeded(down, len(results))
+		if !oneline {
+			fmt.Printf("Down: %.1f%% (%d of %d), threshold: %s\n",
+				downPercent(down, len(results)), down, len(results), threshold)
+			if exceeded {
+				fmt.Println("Threshold exceeded")
+			}
+		}
+		failed = exceeded
+	} else if failFast && down > 0 {
+		failed = true
+	}
+	downFailed := failed // as opposed to failing on regressions alone
+	regressions := countRegressions(results)
+	if regressions > 0 {
+		if !oneline {
+			fmt.Printf("Regressions: %d of %d servers worse than %s\n", regressions, len(results), baselineFile)
+		}
+		failed = true
+	}
+	if tripped != nil {
+		if !warnOnly {
+			log.Printf("Error: %v", tripped)
+		}
+		failed = true
+	}
+
+	if failed {
+		if warnOnly {
+			// Informational runs (e.g. cron) must never fail on server health
+			if tripped != nil {
+				fmt.Printf("Warning: %v (exit status 0 with -warn-only)\n", tripped)
+			} else if !oneline {
+				if downFailed {
+					fmt.Printf("Warning: %d of %d servers DOWN (exit status 0 with -warn-only)\n", down, len(results))
+				}
+				if regressions > 0 {
+					fmt.Printf("Warning: %d regressions against %s (exit status 0 with -warn-only)\n", regressions, baselineFile)
+				}
+			}
+			return
+		}
+		os.Exit(1)
 	}
-}
\ No newline at end of file
+}
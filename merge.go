@@ -0,0 +1,151 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// RegionResult is one region's view of a server in a merged report.
+type RegionResult struct {
+	Status       string `json:"status"`
+	ResponseTime int64  `json:"response_time"`
+	Error        string `json:"error,omitempty"`
+}
+
+// MergedServer is a server's results across every merged region. Split is
+// set when the regions disagree, e.g. UP from us-east but DOWN from eu-west.
+type MergedServer struct {
+	Name    string                  `json:"name"`
+	Regions map[string]RegionResult `json:"regions"`
+	Split   bool                    `json:"split,omitempty"`
+}
+
+// MergedReport combines -report files taken from several regions.
+type MergedReport struct {
+	Timestamp time.Time      `json:"timestamp"`
+	Regions   []string       `json:"regions"`
+	Servers   []MergedServer `json:"servers"`
+	Summary   struct {
+		Total    int `json:"total"`
+		Up       int `json:"up"`       // UP in every region
+		Down     int `json:"down"`     // DOWN in every region
+		Split    int `json:"split"`    // status differs between regions
+		Complete int `json:"complete"` // reported by every region
+	} `json:"summary"`
+}
+
+// regionName names a region after its report file, e.g. "eu-west" for
+// reports/eu-west.json.
+func regionName(filename string) string {
+	base := filepath.Base(filename)
+	return strings.TrimSuffix(base, filepath.Ext(base))
+}
+
+// MergeReports reads the given -report files and combines them by server
+// name, with one entry per region.
+func (m *Monitor) MergeReports(filenames []string) (MergedReport, error) {
+	merged := MergedReport{Timestamp: time.Now().In(m.location)}
+	servers := make(map[string]*MergedServer)
+
+	for _, filename := range filenames {
+		data, err := os.ReadFile(filename)
+		if err != nil {
+			return merged, fmt.Errorf("failed to read report: %v", err)
+		}
+		var report Report
+		if err := json.Unmarshal(data, &report); err != nil {
+			return merged, fmt.Errorf("failed to parse report %s: %v", filename, err)
+		}
+
+		region := regionName(filename)
+		for _, existing := range merged.Regions {
+			if existing == region {
+				return merged, fmt.Errorf("duplicate region %q (from %s)", region, filename)
+			}
+		}
+		merged.Regions = append(merged.Regions, region)
+
+		// Reports written with -group-by list their results per group
+		results := report.Results
+		for _, group := range report.Groups {
+			results = append(results, group.Results...)
+		}
+		for _, result := range results {
+			server, ok := servers[result.Server.Name]
+			if !ok {
+				server = &MergedServer{Name: result.Server.Name, Regions: make(map[string]RegionResult)}
+				servers[result.Server.Name] = server
+			}
+			server.Regions[region] = RegionResult{
+				Status:       result.Status,
+				ResponseTime: result.ResponseTime,
+				Error:        result.Error,
+			}
+		}
+	}
+
+	for _, server := range servers {
+		merged.Summary.Total++
+		statuses := make(map[string]bool)
+		for _, result := range server.Regions {
+			statuses[result.Status] = true
+		}
+		server.Split = len(statuses) > 1
+		switch {
+		case server.Split:
+			merged.Summary.Split++
+		case statuses["UP"]:
+			merged.Summary.Up++
+		case statuses["DOWN"]:
+			merged.Summary.Down++
+		}
+		if len(server.Regions) == len(merged.Regions) {
+			merged.Summary.Complete++
+		}
+		merged.Servers = append(merged.Servers, *server)
+	}
+	sort.Slice(merged.Servers, func(i, j int) bool {
+		return merged.Servers[i].Name < merged.Servers[j].Name
+	})
+	return merged, nil
+}
+
+// printMerged prints a server-by-region status table.
+func (m *Monitor) printMerged(merged MergedReport) {
+	width := len("SERVER")
+	for _, server := range merged.Servers {
+		width = max(width, len(server.Name))
+	}
+
+	fmt.Fprintf(m.out, "%-*s", width, "SERVER")
+	for _, region := range merged.Regions {
+		fmt.Fprintf(m.out, "  %-12s", region)
+	}
+	fmt.Fprintln(m.out)
+
+	for _, server := range merged.Servers {
+		fmt.Fprintf(m.out, "%-*s", width, server.Name)
+		for _, region := range merged.Regions {
+			result, ok := server.Regions[region]
+			if !ok {
+				fmt.Fprintf(m.out, "  %-12s", "-")
+				continue
+			}
+			// Pad before coloring so escape codes don't skew the columns
+			cell := fmt.Sprintf("%-12s", fmt.Sprintf("%s %dms", m.label(result.Status), result.ResponseTime))
+			fmt.Fprintf(m.out, "  %s", m.colorize(result.Status, cell))
+		}
+		if server.Split {
+			fmt.Fprint(m.out, "  (split)")
+		}
+		fmt.Fprintln(m.out)
+	}
+
+	fmt.Fprintf(m.out, "\nSummary: %d servers across %d regions: %d UP everywhere, %d DOWN everywhere, %d split\n",
+		merged.Summary.Total, len(merged.Regions), merged.Summary.Up, merged.Summary.Down, merged.Summary.Split)
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// metricsSnapshot is the latest round's results as served by /metrics. A
+// new snapshot is swapped in atomically after each round and never changed
+// afterwards, so scrapes and checks never wait on each other.
+type metricsSnapshot struct {
+	Timestamp time.Time
+	Results   []HealthResult
+}
+
+// publishMetrics makes a finished round's results visible to /metrics.
+func (m *Monitor) publishMetrics(results []HealthResult) {
+	m.metrics.Store(&metricsSnapshot{
+		Timestamp: time.Now(),
+		Results:   append([]HealthResult(nil), results...),
+	})
+}
+
+func (m *Monitor) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var b strings.Builder
+	b.WriteString("# HELP health_monitor_up Whether the server answered (UP or DEGRADED) in the last round.\n")
+	b.WriteString("# TYPE health_monitor_up gauge\n")
+	snapshot := m.metrics.Load()
+	if snapshot != nil {
+		for _, result := range snapshot.Results {
+			up := 0
+			if result.up() {
+				up = 1
+			}
+			fmt.Fprintf(&b, "health_monitor_up{%s} %d\n", metricLabels(result), up)
+		}
+	}
+
+	b.WriteString("# HELP health_monitor_response_time_ms Response time of the last check.\n")
+	b.WriteString("# TYPE health_monitor_response_time_ms gauge\n")
+	if snapshot != nil {
+		for _, result := range snapshot.Results {
+			fmt.Fprintf(&b, "health_monitor_response_time_ms{%s} %d\n", metricLabels(result), result.ResponseTime)
+		}
+		b.WriteString("# HELP health_monitor_last_round_timestamp_seconds When the last round finished.\n")
+		b.WriteString("# TYPE health_monitor_last_round_timestamp_seconds gauge\n")
+		fmt.Fprintf(&b, "health_monitor_last_round_timestamp_seconds %d\n", snapshot.Timestamp.Unix())
+	}
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	w.Write([]byte(b.String()))
+}
+
+func metricLabels(result HealthResult) string {
+	return fmt.Sprintf("server=%s,host=%s,protocol=%s,status=%s",
+		strconv.Quote(result.Server.Name), strconv.Quote(result.host()),
+		strconv.Quote(result.Server.Protocol), strconv.Quote(result.Status))
+}
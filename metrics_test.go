@@ -0,0 +1,102 @@
+package main
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// testServers starts a local listener and returns n TCP servers, all but
+// the last of which point at it; the last points at a closed port so
+// rounds have a DOWN result too.
+func testServers(t *testing.T, n int) []ServerConfig {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+
+	closed, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	closedPort := closed.Addr().(*net.TCPAddr).Port
+	closed.Close()
+
+	port := listener.Addr().(*net.TCPAddr).Port
+	servers := make([]ServerConfig, n)
+	for i := range servers {
+		servers[i] = ServerConfig{
+			Name:     "server-" + string(rune('a'+i)),
+			Host:     "127.0.0.1",
+			Port:     port,
+			Protocol: "tcp",
+			Timeout:  2,
+		}
+	}
+	servers[n-1].Port = closedPort
+	return servers
+}
+
+// TestScrapeMetricsDuringRounds scrapes /metrics from several goroutines
+// while rounds are running. Every scrape must see either no results or one
+// whole round, never a round being written.
+func TestScrapeMetricsDuringRounds(t *testing.T) {
+	m := NewMonitor()
+	m.out = io.Discard
+	m.servers = testServers(t, 6)
+
+	var running atomic.Bool
+	running.Store(true)
+	var scrapes atomic.Int64
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for running.Load() {
+				rec := httptest.NewRecorder()
+				m.handleMetrics(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+				if rec.Code != http.StatusOK {
+					t.Errorf("scrape returned %d", rec.Code)
+					return
+				}
+				up := strings.Count(rec.Body.String(), "\nhealth_monitor_up{")
+				if up != 0 && up != len(m.servers) {
+					t.Errorf("scrape saw %d of %d servers", up, len(m.servers))
+				}
+				scrapes.Add(1)
+			}
+		}()
+	}
+
+	for i := 0; i < 5; i++ {
+		m.runRound(m.servers)
+	}
+	running.Store(false)
+	wg.Wait()
+
+	if scrapes.Load() == 0 {
+		t.Error("no scrapes completed while rounds ran")
+	}
+	rec := httptest.NewRecorder()
+	m.handleMetrics(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if got := strings.Count(rec.Body.String(), "\nhealth_monitor_up{"); got != len(m.servers) {
+		t.Errorf("final scrape has %d servers, want %d", got, len(m.servers))
+	}
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"fmt"
+	"time"
+)
+
+// OCSP status values recorded in HealthResult.OCSPStatus.
+const (
+	ocspGood    = "good"
+	ocspRevoked = "revoked"
+	ocspUnknown = "unknown"
+	ocspStale   = "stale"
+	ocspMissing = "missing"
+)
+
+// The ASN.1 structures below are the subset of RFC 6960 needed to read the
+// certificate status out of a stapled response. The response arrives inside
+// an already verified TLS handshake, so its signature isn't checked again.
+
+type ocspResponse struct {
+	Status   asn1.Enumerated
+	Response ocspResponseBytes `asn1:"explicit,tag:0,optional"`
+}
+
+type ocspResponseBytes struct {
+	ResponseType asn1.ObjectIdentifier
+	Response     []byte
+}
+
+type ocspBasicResponse struct {
+	TBSResponseData    ocspResponseData
+	SignatureAlgorithm pkix.AlgorithmIdentifier
+	Signature          asn1.BitString
+	Certificates       []asn1.RawValue `asn1:"explicit,tag:0,optional"`
+}
+
+type ocspResponseData struct {
+	Version            int `asn1:"optional,default:0,explicit,tag:0"`
+	RawResponderID     asn1.RawValue
+	ProducedAt         time.Time `asn1:"generalized"`
+	Responses          []ocspSingleResponse
+	ResponseExtensions []pkix.Extension `asn1:"explicit,tag:1,optional"`
+}
+
+type ocspSingleResponse struct {
+	CertID           asn1.RawValue
+	Good             asn1.Flag        `asn1:"tag:0,optional"`
+	Revoked          ocspRevokedInfo  `asn1:"tag:1,optional"`
+	Unknown          asn1.Flag        `asn1:"tag:2,optional"`
+	ThisUpdate       time.Time        `asn1:"generalized"`
+	NextUpdate       time.Time        `asn1:"generalized,explicit,tag:0,optional"`
+	SingleExtensions []pkix.Extension `asn1:"explicit,tag:1,optional"`
+}
+
+type ocspRevokedInfo struct {
+	RevocationTime time.Time       `asn1:"generalized"`
+	Reason         asn1.Enumerated `asn1:"explicit,tag:0,optional"`
+}
+
+var oidOCSPBasic = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 48, 1, 1}
+
+// ocspStatus reports the stapled OCSP status of a TLS connection: good,
+// revoked, unknown, stale (past its nextUpdate) or missing. The error
+// describes any status other than good.
+func ocspStatus(state *tls.ConnectionState) (string, error) {
+	if state == nil || len(state.OCSPResponse) == 0 {
+		return ocspMissing, fmt.Errorf("no OCSP response stapled")
+	}
+
+	var resp ocspResponse
+	if _, err := asn1.Unmarshal(state.OCSPResponse, &resp); err != nil {
+		return ocspUnknown, fmt.Errorf("malformed OCSP response: %v", err)
+	}
+	if resp.Status != 0 {
+		return ocspUnknown, fmt.Errorf("OCSP responder status %d", resp.Status)
+	}
+	if !resp.Response.ResponseType.Equal(oidOCSPBasic) {
+		return ocspUnknown, fmt.Errorf("unsupported OCSP response type %v", resp.Response.ResponseType)
+	}
+	var basic ocspBasicResponse
+	if _, err := asn1.Unmarshal(resp.Response.Response, &basic); err != nil {
+		return ocspUnknown, fmt.Errorf("malformed OCSP response: %v", err)
+	}
+	if len(basic.TBSResponseData.Responses) == 0 {
+		return ocspUnknown, fmt.Errorf("OCSP response has no certificate status")
+	}
+
+	single := basic.TBSResponseData.Responses[0]
+	switch {
+	case bool(single.Good):
+		if !single.NextUpdate.IsZero() && time.Now().After(single.NextUpdate) {
+			return ocspStale, fmt.Errorf("OCSP response expired at %s", single.NextUpdate.Format(time.RFC3339))
+		}
+		return ocspGood, nil
+	case !single.Revoked.RevocationTime.IsZero():
+		return ocspRevoked, fmt.Errorf("certificate revoked at %s", single.Revoked.RevocationTime.Format(time.RFC3339))
+	default:
+		return ocspUnknown, fmt.Errorf("OCSP responder doesn't know the certificate")
+	}
+}
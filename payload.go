@@ -0,0 +1,65 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"regexp"
+	"time"
+)
+
+// payloadReadBytes caps how much of a TCP probe's response is read.
+const payloadReadBytes = 4096
+
+// errPayloadMismatch marks a TCP probe whose response did not match.
+var errPayloadMismatch = errors.New("unexpected response")
+
+// probePayload writes SendPayload to conn, if set, then reads until the
+// response satisfies ExpectPayload (a prefix) and ExpectPayloadRegex, the
+// peer closes the connection, or the deadline passes.
+func probePayload(conn net.Conn, server ServerConfig, deadline time.Time) error {
+	var re *regexp.Regexp
+	if server.ExpectPayloadRegex != "" {
+		var err error
+		re, err = regexp.Compile(server.ExpectPayloadRegex)
+		if err != nil {
+			return fmt.Errorf("invalid expect_payload_regex: %v", err)
+		}
+	}
+
+	conn.SetDeadline(deadline)
+	if server.SendPayload != "" {
+		if _, err := io.WriteString(conn, server.SendPayload); err != nil {
+			return err
+		}
+	}
+	if server.ExpectPayload == "" && re == nil {
+		return nil
+	}
+
+	matches := func(data []byte) bool {
+		if !bytes.HasPrefix(data, []byte(server.ExpectPayload)) {
+			return false
+		}
+		return re == nil || re.Match(data)
+	}
+
+	var response []byte
+	buf := make([]byte, 512)
+	for len(response) < payloadReadBytes {
+		n, err := conn.Read(buf)
+		response = append(response, buf[:n]...)
+		if matches(response) {
+			return nil
+		}
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return fmt.Errorf("%w %q", errPayloadMismatch, snippet(response))
+}
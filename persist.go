@@ -0,0 +1,94 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// savedState is a server's entry in the -state file.
+type savedState struct {
+	Status    string     `json:"status"`
+	LastCheck string     `json:"last_check"`
+	Streak    int        `json:"streak"`
+	Since     time.Time  `json:"since"`
+	LastError string     `json:"last_error,omitempty"`
+	LastAlert *time.Time `json:"last_alert,omitempty"`
+}
+
+// SaveState writes every server's alert state to filename so a restarted
+// monitor carries on where this one stopped.
+func (m *Monitor) SaveState(filename string) error {
+	m.mu.Lock()
+	saved := make(map[string]savedState, len(m.states))
+	for name, state := range m.states {
+		entry := savedState{
+			Status:    state.Status,
+			LastCheck: state.LastCheck,
+			Streak:    state.Streak,
+			Since:     state.Since,
+			LastError: state.LastError,
+		}
+		if !state.LastAlert.IsZero() {
+			lastAlert := state.LastAlert
+			entry.LastAlert = &lastAlert
+		}
+		saved[name] = entry
+	}
+	m.mu.Unlock()
+
+	data, err := json.MarshalIndent(saved, "", "  ")
+	if err != nil {
+		return err
+	}
+	// Write then rename so a crash mid-write never leaves a truncated file
+	tmp := filename + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to save state: %v", err)
+	}
+	if err := os.Rename(tmp, filename); err != nil {
+		return fmt.Errorf("failed to save state: %v", err)
+	}
+	return nil
+}
+
+// LoadState restores the alert state saved by SaveState for servers that
+// are still configured. A missing file is not an error: it is the first run.
+func (m *Monitor) LoadState(filename string) (int, error) {
+	data, err := os.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to read state: %v", err)
+	}
+
+	var saved map[string]savedState
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return 0, fmt.Errorf("failed to parse state %s: %v", filename, err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	restored := 0
+	for _, server := range m.servers {
+		s, ok := saved[server.Name]
+		if !ok {
+			continue
+		}
+		state := &serverState{
+			Status:    s.Status,
+			LastCheck: s.LastCheck,
+			Streak:    s.Streak,
+			Since:     s.Since,
+			LastError: s.LastError,
+		}
+		if s.LastAlert != nil {
+			state.LastAlert = *s.LastAlert
+		}
+		m.states[server.Name] = state
+		restored++
+	}
+	return restored, nil
+}
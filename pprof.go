@@ -0,0 +1,34 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/http/pprof"
+)
+
+// startPprof serves the net/http/pprof handlers on addr so a long-running
+// monitor can be inspected with `go tool pprof`. It uses its own mux so the
+// profiles are never exposed on the status server's address.
+func startPprof(addr string) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to start pprof server: %v", err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+
+	go func() {
+		if err := http.Serve(listener, mux); err != nil {
+			log.Printf("pprof server stopped: %v", err)
+		}
+	}()
+	fmt.Printf("pprof listening on http://%s/debug/pprof/\n", listener.Addr())
+	return nil
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+)
+
+// defaultReadyTag is the tag of the servers that gate /readyz.
+const defaultReadyTag = "dependency"
+
+// StartProbeServer serves Kubernetes-style health endpoints on addr, so the
+// monitor can run as a sidecar whose readiness follows its dependencies:
+//
+//	GET /livez   200 while the monitor is running
+//	GET /readyz  200 only if every server tagged tag is confirmed UP
+func (m *Monitor) StartProbeServer(addr, tag string) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to start probe server: %v", err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/livez", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, "ok")
+	})
+	mux.HandleFunc("/readyz", m.handleReady(tag))
+
+	go func() {
+		if err := http.Serve(listener, mux); err != nil {
+			log.Printf("Probe server stopped: %v", err)
+		}
+	}()
+	fmt.Printf("Probe server listening on %s (/readyz follows servers tagged %q)\n", listener.Addr(), tag)
+	if len(serversWithTag(m.currentServers(), tag)) == 0 {
+		fmt.Printf("Warning: no servers are tagged %q, so /readyz is always ready\n", tag)
+	}
+	return nil
+}
+
+// handleReady reports ready only when every server with tag has a confirmed
+// UP (or DEGRADED) state. Servers not checked yet count as not ready, so a
+// starting pod doesn't receive traffic before its dependencies are known.
+func (m *Monitor) handleReady(tag string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		servers := serversWithTag(m.currentServers(), tag)
+		statuses := make(map[string]string, len(servers))
+		ready := true
+
+		m.mu.Lock()
+		for _, server := range servers {
+			status := "UNKNOWN"
+			if state, ok := m.states[server.Name]; ok {
+				status = state.Status
+			}
+			statuses[server.Name] = status
+			if status != "UP" && status != "DEGRADED" {
+				ready = false
+			}
+		}
+		m.mu.Unlock()
+
+		code := http.StatusOK
+		if !ready {
+			code = http.StatusServiceUnavailable
+		}
+		writeJSON(w, code, struct {
+			Ready   bool              `json:"ready"`
+			Servers map[string]string `json:"servers"`
+		}{ready, statuses})
+	}
+}
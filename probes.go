@@ -0,0 +1,65 @@
+package main
+
+import "context"
+
+// ProbeStats summarizes the probes of a check with -probe-count or
+// ProbeCount above 1. The result's response time is AvgMs.
+type ProbeStats struct {
+	Count     int   `json:"count"`
+	Succeeded int   `json:"succeeded"`
+	AvgMs     int64 `json:"avg_ms"` // over the successful probes, or all if none succeeded
+	MinMs     int64 `json:"min_ms"`
+	MaxMs     int64 `json:"max_ms"`
+}
+
+// probeCount is how many probes make up one check of server.
+func (m *Monitor) probeCount(server ServerConfig) int {
+	if server.ProbeCount > 0 {
+		return server.ProbeCount
+	}
+	return max(m.probes, 1)
+}
+
+// sampleProtocol probes server probeCount times in a row and combines the
+// results: UP only if a majority of probes succeeded, with the average
+// response time. A single probe is returned as is.
+func (m *Monitor) sampleProtocol(ctx context.Context, server ServerConfig) HealthResult {
+	count := m.probeCount(server)
+	if count == 1 {
+		return m.probeProtocol(ctx, server)
+	}
+
+	stats := &ProbeStats{Count: count}
+	var lastUp, lastDown HealthResult
+	var upTotal, allTotal int64
+	for i := 0; i < count; i++ {
+		result := m.probeProtocol(ctx, server)
+		if i == 0 || result.ResponseTime < stats.MinMs {
+			stats.MinMs = result.ResponseTime
+		}
+		stats.MaxMs = max(stats.MaxMs, result.ResponseTime)
+		allTotal += result.ResponseTime
+		if result.up() {
+			stats.Succeeded++
+			upTotal += result.ResponseTime
+			lastUp = result
+		} else {
+			lastDown = result
+		}
+		if ctx.Err() != nil {
+			// Cancelled (-fail-fast): report the probes that ran
+			stats.Count = i + 1
+			break
+		}
+	}
+
+	result := lastDown
+	stats.AvgMs = allTotal / int64(stats.Count)
+	if stats.Succeeded*2 > stats.Count {
+		result = lastUp
+		stats.AvgMs = upTotal / int64(stats.Succeeded)
+	}
+	result.ResponseTime = stats.AvgMs
+	result.Probes = stats
+	return result
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Checker runs the check of one protocol against a server. The built-in
+// protocols are Checkers, and RegisterChecker adds more.
+type Checker interface {
+	Check(ctx context.Context, server ServerConfig) HealthResult
+}
+
+// CheckerFunc adapts a function to the Checker interface.
+type CheckerFunc func(ctx context.Context, server ServerConfig) HealthResult
+
+func (f CheckerFunc) Check(ctx context.Context, server ServerConfig) HealthResult {
+	return f(ctx, server)
+}
+
+// protocol is a built-in check protocol: its Checker, and what
+// -list-protocols says about it.
+type protocol struct {
+	name        string
+	description string
+	required    []string // config fields the check needs besides name and protocol
+	optional    []string // config fields only this protocol uses
+	check       func(m *Monitor, ctx context.Context, server ServerConfig) HealthResult
+}
+
+var httpFields = []string{
+	"path", "url", "headers", "username", "password", "password_file", "cookies", "login",
+	"server_name", "max_redirects", "expect_body", "expect_body_file", "expect_json", "expect_headers",
+	"degraded_status", "differ_from", "interface",
+}
+
+// protocols are the supported values of ServerConfig.Protocol, in the order
+// -list-protocols prints them.
+var protocols = []protocol{
+	{
+		name:        "tcp",
+		description: "Connect to host:port, optionally exchanging a payload",
+		required:    []string{"host", "port", "timeout"},
+		optional:    []string{"send_payload", "expect_payload", "expect_payload_regex", "interface", "resolver"},
+		check:       (*Monitor).checkTCP,
+	},
+	{
+		name:        "http",
+		description: "GET a URL and require a 2xx/3xx status, optionally checking the response",
+		required:    []string{"host", "port", "timeout"},
+		optional:    httpFields,
+		check:       (*Monitor).checkHTTP,
+	},
+	{
+		name:        "https",
+		description: "Like http over TLS, optionally checking the certificate",
+		required:    []string{"host", "port", "timeout"},
+		optional:    append(append([]string{}, httpFields...), "expect_issuer", "expect_san", "require_ocsp"),
+		check:       (*Monitor).checkHTTP,
+	},
+	{
+		name:        "dns",
+		description: "Resolve host, optionally checking the records and their TTL",
+		required:    []string{"host", "timeout"},
+		optional:    []string{"resolver", "expect_ip", "expect_cname", "min_ttl", "max_ttl"},
+		check:       (*Monitor).checkDNS,
+	},
+	{
+		name:        "kafka",
+		description: "Send an ApiVersions request to a Kafka broker",
+		required:    []string{"host", "port", "timeout"},
+		optional:    []string{"interface", "resolver"},
+		check:       (*Monitor).checkKafka,
+	},
+}
+
+// registerBuiltinCheckers registers a Checker for each built-in protocol.
+func (m *Monitor) registerBuiltinCheckers() {
+	for _, p := range protocols {
+		check := p.check
+		m.RegisterChecker(p.name, CheckerFunc(func(ctx context.Context, server ServerConfig) HealthResult {
+			return check(m, ctx, server)
+		}))
+	}
+}
+
+// RegisterChecker makes servers with the given protocol use checker,
+// replacing the built-in check if there is one. Register checkers before
+// checks start; the registry is not locked.
+func (m *Monitor) RegisterChecker(name string, checker Checker) {
+	m.checkers[name] = checker
+}
+
+// printProtocols writes the -list-protocols listing: the built-in protocols,
+// then any others registered with RegisterChecker.
+func (m *Monitor) printProtocols(w io.Writer) {
+	builtin := make(map[string]bool, len(protocols))
+	for _, p := range protocols {
+		builtin[p.name] = true
+		fmt.Fprintf(w, "%-6s %s\n", p.name, p.description)
+		fmt.Fprintf(w, "       required: %s\n", strings.Join(p.required, ", "))
+		fmt.Fprintf(w, "       optional: %s\n", strings.Join(p.optional, ", "))
+	}
+	var others []string
+	for name := range m.checkers {
+		if !builtin[name] {
+			others = append(others, name)
+		}
+	}
+	sort.Strings(others)
+	for _, name := range others {
+		fmt.Fprintf(w, "%-6s (registered checker)\n", name)
+	}
+	fmt.Fprintln(w, "\nFields for every protocol (timeout, retries, schedule, ...) are listed in the README.")
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/proxy"
+)
+
+// dialFunc matches net.Dialer.DialContext so checks can dial either directly
+// or through a tunnel.
+type dialFunc func(ctx context.Context, network, address string) (net.Conn, error)
+
+// newSOCKS5Dialer builds a dialer that tunnels every connection through the
+// SOCKS5 proxy at spec, given as [socks5://][user:pass@]host:port.
+func newSOCKS5Dialer(spec string) (dialFunc, error) {
+	if !strings.Contains(spec, "://") {
+		spec = "socks5://" + spec
+	}
+	u, err := url.Parse(spec)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SOCKS5 proxy: %v", err)
+	}
+	if u.Scheme != "socks5" && u.Scheme != "socks5h" {
+		return nil, fmt.Errorf("invalid SOCKS5 proxy: unsupported scheme %q", u.Scheme)
+	}
+	if u.Port() == "" {
+		return nil, fmt.Errorf("invalid SOCKS5 proxy: missing port in %q", u.Host)
+	}
+
+	var auth *proxy.Auth
+	if u.User != nil {
+		password, _ := u.User.Password()
+		auth = &proxy.Auth{User: u.User.Username(), Password: password}
+	}
+
+	dialer, err := proxy.SOCKS5("tcp", u.Host, auth, &net.Dialer{})
+	if err != nil {
+		return nil, fmt.Errorf("invalid SOCKS5 proxy: %v", err)
+	}
+	contextDialer, ok := dialer.(proxy.ContextDialer)
+	if !ok {
+		return nil, fmt.Errorf("invalid SOCKS5 proxy: dialer does not support contexts")
+	}
+
+	proxyAddr := u.Host
+	return func(ctx context.Context, network, address string) (net.Conn, error) {
+		conn, err := contextDialer.DialContext(ctx, network, address)
+		if err != nil {
+			return nil, fmt.Errorf("via SOCKS5 proxy %s: %v", proxyAddr, err)
+		}
+		return conn, nil
+	}, nil
+}
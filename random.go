@@ -0,0 +1,47 @@
+package main
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// random is the single source of randomness for the monitor (e.g. retry
+// backoff jitter), so that a -seed reproduces a run's behavior exactly.
+var random = newLockedRand(time.Now().UnixNano())
+
+// randomSeed is the seed random was last seeded with, printed at startup so
+// a run can be repeated with -seed.
+var randomSeed int64
+
+type lockedRand struct {
+	mu sync.Mutex
+	r  *rand.Rand
+}
+
+func newLockedRand(seed int64) *lockedRand {
+	randomSeed = seed
+	return &lockedRand{r: rand.New(rand.NewSource(seed))}
+}
+
+// seedRandom reseeds the package RNG.
+func seedRandom(seed int64) {
+	random.mu.Lock()
+	defer random.mu.Unlock()
+	random.r.Seed(seed)
+	randomSeed = seed
+}
+
+// Float64 returns a pseudo-random number in [0.0, 1.0).
+func (l *lockedRand) Float64() float64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.r.Float64()
+}
+
+// Int63n returns a pseudo-random number in [0, n).
+func (l *lockedRand) Int63n(n int64) int64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.r.Int63n(n)
+}
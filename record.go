@@ -0,0 +1,147 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// recordedRound is one line of a -record file: the results of a single
+// check round, with secrets redacted.
+type recordedRound struct {
+	Timestamp time.Time      `json:"timestamp"`
+	Results   []HealthResult `json:"results"`
+}
+
+// recorder appends every check round to a JSON-lines file for -replay.
+// With a maxSize the file is rotated before it would grow past it, keeping
+// maxFiles older files.
+type recorder struct {
+	filename string
+	file     *os.File
+	size     int64
+	maxSize  int64
+	maxFiles int
+}
+
+func newRecorder(filename string, maxSize int64, maxFiles int) (*recorder, error) {
+	r := &recorder{filename: filename, maxSize: maxSize, maxFiles: maxFiles}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *recorder) open() error {
+	file, err := os.OpenFile(r.filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open recording: %v", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("failed to open recording: %v", err)
+	}
+	r.file, r.size = file, info.Size()
+	return nil
+}
+
+// write appends one round as a line, rotating first if needed.
+func (r *recorder) write(round recordedRound) error {
+	line, err := json.Marshal(round)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	if r.maxSize > 0 && r.size > 0 && r.size+int64(len(line)) > r.maxSize {
+		r.file.Close()
+		if err := rotateFiles(r.filename, r.maxFiles); err != nil {
+			return fmt.Errorf("failed to rotate recording: %v", err)
+		}
+		if err := r.open(); err != nil {
+			return err
+		}
+	}
+
+	n, err := r.file.Write(line)
+	r.size += int64(n)
+	return err
+}
+
+func (r *recorder) Close() error {
+	return r.file.Close()
+}
+
+// record writes a finished round to the recording, if one is open.
+func (m *Monitor) record(results []HealthResult) {
+	if m.recorder == nil {
+		return
+	}
+
+	round := recordedRound{
+		Timestamp: time.Now(),
+		Results:   make([]HealthResult, len(results)),
+	}
+	for i, result := range results {
+		result.Server = result.Server.redacted()
+		round.Results[i] = result
+	}
+
+	m.recordMu.Lock()
+	defer m.recordMu.Unlock()
+	if err := m.recorder.write(round); err != nil {
+		fmt.Fprintf(m.out, "Warning: failed to record round: %v\n", err)
+	}
+}
+
+// Replay feeds the rounds of a -record file through the normal output,
+// state and alert pipeline. The recorded gaps between rounds are kept,
+// divided by speed; a speed of 0 replays without waiting.
+func (m *Monitor) Replay(filename string, speed float64) error {
+	file, err := os.Open(filename)
+	if err != nil {
+		return fmt.Errorf("failed to open recording: %v", err)
+	}
+	defer file.Close()
+
+	fmt.Fprintf(m.out, "Replaying %s (speed: %gx)\n", filename, speed)
+
+	dec := json.NewDecoder(file)
+	var previous time.Time
+	rounds := 0
+	for {
+		var round recordedRound
+		if err := dec.Decode(&round); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return fmt.Errorf("invalid recording %s after %d rounds: %v", filename, rounds, err)
+		}
+
+		if speed > 0 && !previous.IsZero() && round.Timestamp.After(previous) {
+			time.Sleep(time.Duration(float64(round.Timestamp.Sub(previous)) / speed))
+		}
+		previous = round.Timestamp
+		rounds++
+
+		fmt.Fprintf(m.out, "\n--- Replayed Health Check at %s ---\n", m.formatTime(round.Timestamp.In(m.location)))
+		var upCount, downCount int
+		for _, result := range round.Results {
+			m.printResult(result)
+			if result.Status == "DOWN" {
+				downCount++
+			} else if result.up() {
+				upCount++
+			}
+		}
+		fmt.Fprintf(m.out, "\nSummary: %d %s, %d %s\n", upCount, m.label("UP"), downCount, m.label("DOWN"))
+		m.warnDNSOutage(round.Results)
+		m.updateStates(round.Results)
+	}
+
+	fmt.Fprintf(m.out, "\nReplayed %d rounds\n", rounds)
+	return nil
+}
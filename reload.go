@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// watchConfig sends on reload each time the config file at path changes,
+// debounced like -watch-file, or the process receives SIGHUP, until stop is
+// closed.
+func (m *Monitor) watchConfig(path string, reload chan<- struct{}, stop <-chan struct{}) {
+	ticker := time.NewTicker(watchPollInterval)
+	defer ticker.Stop()
+
+	hangups := make(chan os.Signal, 1)
+	signal.Notify(hangups, syscall.SIGHUP)
+	defer signal.Stop(hangups)
+
+	last := statFile(path)
+	var changedAt time.Time // zero when no change is pending
+	for {
+		select {
+		case <-ticker.C:
+			if stamp := statFile(path); stamp != last {
+				last = stamp
+				changedAt = time.Now()
+				continue
+			}
+			if changedAt.IsZero() || time.Since(changedAt) < watchDebounce {
+				continue
+			}
+			changedAt = time.Time{}
+		case <-hangups:
+			last = statFile(path)
+			changedAt = time.Time{}
+		case <-stop:
+			return
+		}
+
+		select {
+		case reload <- struct{}{}:
+		case <-stop:
+			return
+		}
+	}
+}
+
+// reloadConfig loads and validates the config file and, only if it is
+// valid, swaps it in between rounds. A broken config leaves the running one
+// in place and is reported loudly, since a silently stale config is easy to
+// miss. It reports whether the new config was applied.
+func (m *Monitor) reloadConfig(path string) bool {
+	var settings configSettings
+	servers, err := m.loadConfigFile(path, nil, make(map[string]string), &settings)
+	var enabled []ServerConfig
+	var disabled int
+	if err == nil {
+		enabled, disabled, err = validateServers(servers)
+	}
+	if err != nil {
+		fmt.Fprintf(m.out, "\n*** Config reload FAILED at %s: %v\n", m.formatTime(time.Now()), err)
+		fmt.Fprintf(m.out, "*** Still monitoring the previous %d servers; fix %s to retry\n", len(m.servers), path)
+		return false
+	}
+
+	m.roundMu.Lock()
+	m.mu.Lock()
+	previous := len(m.servers)
+	m.servers, m.excluded = m.excludeServers(enabled)
+	m.disabled = disabled
+	m.applySettings(settings)
+	if m.watchdog != nil {
+		// Rounds, which feed the watchdog, are held off by roundMu
+		m.watchdog.prune(m.servers)
+	}
+	m.mu.Unlock()
+	m.roundMu.Unlock()
+
+	fmt.Fprintf(m.out, "\nConfig reloaded from %s: %d servers (was %d)", path, len(m.servers), previous)
+	if disabled > 0 {
+		fmt.Fprintf(m.out, ", %d disabled", disabled)
+	}
+	fmt.Fprintln(m.out)
+	return true
+}
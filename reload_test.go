@@ -0,0 +1,71 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestReloadConfigKeepsOldConfigWhenInvalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "servers.json")
+	valid := `{"concurrency": 4, "servers": [
+		{"name": "a", "host": "127.0.0.1", "port": 1, "protocol": "tcp", "timeout": 1},
+		{"name": "b", "host": "127.0.0.1", "port": 2, "protocol": "tcp", "timeout": 1}
+	]}`
+	if err := os.WriteFile(path, []byte(valid), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	m := NewMonitor()
+	m.out = io.Discard
+	if !m.reloadConfig(path) {
+		t.Fatal("reloadConfig rejected a valid config")
+	}
+	if len(m.servers) != 2 || m.configConcurrency != 4 {
+		t.Fatalf("after valid reload: %d servers, concurrency %d; want 2 and 4", len(m.servers), m.configConcurrency)
+	}
+	before := append([]ServerConfig(nil), m.servers...)
+
+	invalid := []string{
+		`{"servers": [`,
+		`{"concurrency": 0, "servers": []}`,
+		`{"concurrency": 8, "servers": [{"name": "c", "protocol": "tcp", "host": "x", "port": 80, "timeout": 1,
+			"depends_on": "missing"}]}`,
+	}
+	for _, config := range invalid {
+		if err := os.WriteFile(path, []byte(config), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if m.reloadConfig(path) {
+			t.Errorf("reloadConfig accepted invalid config %s", config)
+		}
+		if !reflect.DeepEqual(m.servers, before) {
+			t.Errorf("servers changed after rejected config %s: %+v", config, m.servers)
+		}
+		if m.configConcurrency != 4 {
+			t.Errorf("concurrency changed to %d after rejected config %s", m.configConcurrency, config)
+		}
+	}
+}
+
+func TestReloadConfigPrunesErrorRateWatchdog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "servers.json")
+	config := `{"servers": [{"name": "a", "host": "127.0.0.1", "port": 1, "protocol": "tcp", "timeout": 1}]}`
+	if err := os.WriteFile(path, []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	m := NewMonitor()
+	m.out = io.Discard
+	m.watchdog = newErrorRateWatchdog(downThreshold{Count: 1}, 0)
+	m.watchdog.latest = map[string]string{"a": "UP", "a (IPv4)": "UP", "gone": "DOWN", "gone (IPv6)": "DOWN"}
+	if !m.reloadConfig(path) {
+		t.Fatal("reloadConfig rejected a valid config")
+	}
+	want := map[string]string{"a": "UP", "a (IPv4)": "UP"}
+	if !reflect.DeepEqual(m.watchdog.latest, want) {
+		t.Errorf("watchdog counts %v after reload, want %v", m.watchdog.latest, want)
+	}
+}
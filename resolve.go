@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http/httptrace"
+	"time"
+)
+
+// targets returns the checks to run for a server: the server itself, or with
+// -resolve one copy per address family its host resolves to, each pinned to
+// the first address of that family, or with -resolve-all one copy pinned to
+// each address.
+func (m *Monitor) targets(ctx context.Context, server ServerConfig) []ServerConfig {
+	if !m.perFamily && !m.allAddrs || !dialsHost(server) {
+		return []ServerConfig{server}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(server.Timeout)*time.Second)
+	defer cancel()
+	addrs, err := resolverFor(server).LookupIPAddr(ctx, server.Host)
+	if err != nil || len(addrs) == 0 {
+		// The regular check reports the resolution failure
+		return []ServerConfig{server}
+	}
+
+	var targets []ServerConfig
+	var seenV4, seenV6 bool
+	for _, addr := range addrs {
+		if m.allAddrs {
+			target := server
+			target.Name = fmt.Sprintf("%s (%s)", server.Name, addr.IP)
+			target.dialIP = addr.IP.String()
+			targets = append(targets, target)
+			continue
+		}
+		family := "IPv6"
+		if addr.IP.To4() != nil {
+			if seenV4 {
+				continue
+			}
+			seenV4 = true
+			family = "IPv4"
+		} else {
+			if seenV6 {
+				continue
+			}
+			seenV6 = true
+		}
+
+		target := server
+		target.Name = fmt.Sprintf("%s (%s)", server.Name, family)
+		target.dialIP = addr.IP.String()
+		targets = append(targets, target)
+	}
+	return targets
+}
+
+// dialsHost reports whether the server's check connects to a hostname that
+// could resolve to several addresses.
+func dialsHost(server ServerConfig) bool {
+	switch server.Protocol {
+	case "tcp", "http", "https", "kafka":
+	default:
+		return false
+	}
+	return len(server.Hosts) == 0 && net.ParseIP(server.Host) == nil
+}
+
+// resolverFor returns the resolver for a server's lookups: one that queries
+// its Resolver nameserver directly, or the system resolver.
+func resolverFor(server ServerConfig) *net.Resolver {
+	if server.Resolver == "" {
+		return net.DefaultResolver
+	}
+	nameserver := nameserverAddress(server.Resolver)
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			var dialer net.Dialer
+			return dialer.DialContext(ctx, network, nameserver)
+		},
+	}
+}
+
+// dialViaResolver resolves the host of address with the server's Resolver
+// and dials the addresses it returns with dial, in order until one connects.
+func (m *Monitor) dialViaResolver(server ServerConfig, dial dialFunc) dialFunc {
+	resolver := resolverFor(server)
+	return func(ctx context.Context, network, address string) (net.Conn, error) {
+		host, port, err := net.SplitHostPort(address)
+		if err != nil {
+			return nil, err
+		}
+		if net.ParseIP(host) != nil {
+			return dial(ctx, network, address)
+		}
+		// LookupHost doesn't report to a trace as the dialer's own lookup
+		// does, so report it here for the check's dns_ms
+		trace := httptrace.ContextClientTrace(ctx)
+		if trace != nil && trace.DNSStart != nil {
+			trace.DNSStart(httptrace.DNSStartInfo{Host: host})
+		}
+		addrs, err := resolver.LookupHost(ctx, host)
+		if trace != nil && trace.DNSDone != nil {
+			trace.DNSDone(httptrace.DNSDoneInfo{Err: err})
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, addr := range addrs {
+			var conn net.Conn
+			conn, err = dial(ctx, network, net.JoinHostPort(addr, port))
+			if err == nil {
+				return conn, nil
+			}
+		}
+		return nil, err
+	}
+}
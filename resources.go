@@ -0,0 +1,114 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// checksPerCPU is how many concurrent checks are allowed per available CPU.
+// Checks are I/O bound, so this is well above one.
+const checksPerCPU = 32
+
+// containerCPULimit returns the CPU quota imposed by the cgroup (v2 or v1),
+// or 0 if there is none or it cannot be determined.
+func containerCPULimit() float64 {
+	// cgroup v2: "<quota> <period>" or "max <period>"
+	if data, err := os.ReadFile("/sys/fs/cgroup/cpu.max"); err == nil {
+		fields := strings.Fields(string(data))
+		if len(fields) != 2 || fields[0] == "max" {
+			return 0
+		}
+		quota, err1 := strconv.ParseFloat(fields[0], 64)
+		period, err2 := strconv.ParseFloat(fields[1], 64)
+		if err1 != nil || err2 != nil || period <= 0 {
+			return 0
+		}
+		return quota / period
+	}
+
+	// cgroup v1: a quota of -1 means unlimited
+	quota, err1 := readCgroupInt("/sys/fs/cgroup/cpu/cpu.cfs_quota_us")
+	period, err2 := readCgroupInt("/sys/fs/cgroup/cpu/cpu.cfs_period_us")
+	if err1 != nil || err2 != nil || quota <= 0 || period <= 0 {
+		return 0
+	}
+	return float64(quota) / float64(period)
+}
+
+func readCgroupInt(path string) (int64, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64)
+}
+
+// availableCPUs is GOMAXPROCS, further capped by any container CPU limit.
+func availableCPUs() int {
+	cpus := runtime.GOMAXPROCS(0)
+	if limit := containerCPULimit(); limit > 0 {
+		if n := int(math.Ceil(limit)); n < cpus {
+			cpus = n
+		}
+	}
+	return cpus
+}
+
+func defaultConcurrency() int {
+	return availableCPUs() * checksPerCPU
+}
+
+// printHeartbeat reports the monitor's own resource usage.
+func (m *Monitor) printHeartbeat() {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	fmt.Fprintf(m.out, "Heartbeat: goroutines=%d heap=%.1fMB sys=%.1fMB gc=%d max_concurrency=%d\n",
+		runtime.NumGoroutine(),
+		float64(mem.HeapAlloc)/(1<<20),
+		float64(mem.Sys)/(1<<20),
+		mem.NumGC,
+		m.maxConcurrency)
+}
+
+// parsePoolLimit parses a -pool value of the form name=limit.
+func parsePoolLimit(s string) (string, int, error) {
+	name, value, ok := strings.Cut(s, "=")
+	if !ok || name == "" {
+		return "", 0, fmt.Errorf("invalid pool %q: expected name=limit", s)
+	}
+	limit, err := strconv.Atoi(value)
+	if err != nil || limit < 1 {
+		return "", 0, fmt.Errorf("invalid pool %q: limit must be a positive integer", s)
+	}
+	return name, limit, nil
+}
+
+// poolLimit is the concurrency limit of the named pool.
+func (m *Monitor) poolLimit(pool string) int {
+	if limit, ok := m.poolLimits[pool]; ok {
+		return limit
+	}
+	return m.maxConcurrency
+}
+
+// groupByPool splits servers by concurrency pool, keeping the config order
+// within each pool and ordering pools by first appearance.
+func groupByPool(servers []ServerConfig) [][]ServerConfig {
+	var pools [][]ServerConfig
+	index := make(map[string]int)
+	for _, server := range servers {
+		i, ok := index[server.Pool]
+		if !ok {
+			i = len(pools)
+			index[server.Pool] = i
+			pools = append(pools, nil)
+		}
+		pools[i] = append(pools[i], server)
+	}
+	return pools
+}
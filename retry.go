@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	// defaultRetryBackoffBase and defaultRetryBackoffMax bound the delay
+	// before retries unless a server sets its own
+	defaultRetryBackoffBase = 100 * time.Millisecond
+	defaultRetryBackoffMax  = 5 * time.Second
+)
+
+// retryBackoff returns the delay before retry n (from 0) using "full
+// jitter": a random duration up to base*2^n, capped at max. Spreading the
+// retries out keeps many servers failing together from retrying in lockstep.
+func retryBackoff(server ServerConfig, n int) time.Duration {
+	base := time.Duration(server.RetryBackoffBase) * time.Millisecond
+	if base <= 0 {
+		base = defaultRetryBackoffBase
+	}
+	ceiling := time.Duration(server.RetryBackoffMax) * time.Millisecond
+	if ceiling <= 0 {
+		ceiling = defaultRetryBackoffMax
+	}
+	for i := 0; i < n && base < ceiling; i++ {
+		base *= 2
+	}
+	return time.Duration(random.Int63n(int64(min(base, ceiling)) + 1))
+}
+
+// checkWithRetries runs a check, retrying it up to server.Retries times with
+// backoff while it is DOWN. The last attempt's result is returned, with the
+// number of attempts and the total time spent retrying.
+func (m *Monitor) checkWithRetries(ctx context.Context, server ServerConfig) HealthResult {
+	result := m.attemptCheck(ctx, server)
+	if server.Retries <= 0 || result.Status != "DOWN" {
+		return result
+	}
+
+	start := time.Now()
+	attempts := 1
+retry:
+	for n := 0; n < server.Retries && result.Status == "DOWN"; n++ {
+		timer := time.NewTimer(retryBackoff(server, n))
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			// Cancelled, e.g. by -fail-fast: keep the failure we have
+			timer.Stop()
+			break retry
+		}
+		result = m.attemptCheck(ctx, server)
+		attempts++
+	}
+	result.Attempts = attempts
+	result.RetryMs = time.Since(start).Milliseconds()
+	return result
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// defaultRecordMaxFiles is how many rotated -record files are kept when
+// -record-max-size is set.
+const defaultRecordMaxFiles = 5
+
+// parseSize parses a size such as "512", "64KB" or "10MB" (binary units).
+func parseSize(s string) (int64, error) {
+	upper := strings.ToUpper(strings.TrimSpace(s))
+	multiplier := int64(1)
+	for _, unit := range []struct {
+		suffix string
+		size   int64
+	}{{"GB", 1 << 30}, {"G", 1 << 30}, {"MB", 1 << 20}, {"M", 1 << 20}, {"KB", 1 << 10}, {"K", 1 << 10}, {"B", 1}} {
+		if strings.HasSuffix(upper, unit.suffix) {
+			upper = strings.TrimSuffix(upper, unit.suffix)
+			multiplier = unit.size
+			break
+		}
+	}
+	n, err := strconv.ParseInt(strings.TrimSpace(upper), 10, 64)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("want a positive size such as 10MB")
+	}
+	return n * multiplier, nil
+}
+
+// rotateFiles shifts filename to filename.1, filename.1 to filename.2 and
+// so on, like logrotate, deleting the oldest so that at most keep rotated
+// files remain.
+func rotateFiles(filename string, keep int) error {
+	os.Remove(fmt.Sprintf("%s.%d", filename, keep))
+	for n := keep - 1; n >= 1; n-- {
+		from := fmt.Sprintf("%s.%d", filename, n)
+		if err := os.Rename(from, fmt.Sprintf("%s.%d", filename, n+1)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return os.Rename(filename, filename+".1")
+}
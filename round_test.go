@@ -0,0 +1,85 @@
+package main
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+// TestRoundsAndSinksRace runs rounds concurrently, as the status server's
+// /check does alongside continuous rounds, while the metrics snapshot, the
+// server stats and the report and recording sinks consume their results.
+// It is meant for go test -race.
+func TestRoundsAndSinksRace(t *testing.T) {
+	dir := t.TempDir()
+	m := NewMonitor()
+	m.out = io.Discard
+	m.servers = testServers(t, 8)
+	recording := filepath.Join(dir, "rounds.jsonl")
+	rec, err := newRecorder(recording, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.recorder = rec
+	defer rec.Close()
+
+	const rounders, rounds = 4, 3
+	done := make(chan struct{})
+	var readers sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		readers.Add(1)
+		go func() {
+			defer readers.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				if snapshot := m.metrics.Load(); snapshot != nil && len(snapshot.Results) != len(m.servers) {
+					t.Errorf("metrics snapshot has %d results, want %d", len(snapshot.Results), len(m.servers))
+				}
+				m.Stats()
+			}
+		}()
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < rounders; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < rounds; j++ {
+				results := m.runRound(m.servers)
+				if len(results) != len(m.servers) {
+					t.Errorf("round returned %d results, want %d", len(results), len(m.servers))
+				}
+				report := filepath.Join(dir, "report-"+string(rune('a'+i))+".json")
+				if err := m.WriteReports([]string{report}, results); err != nil {
+					t.Error(err)
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(done)
+	readers.Wait()
+
+	file, err := os.Open(recording)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	lines := 0
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		lines++
+	}
+	if lines != rounders*rounds {
+		t.Errorf("recorded %d rounds, want %d", lines, rounders*rounds)
+	}
+}
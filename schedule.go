@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// scheduleGroup is a set of servers checked together on one interval.
+type scheduleGroup struct {
+	interval time.Duration
+	servers  []ServerConfig
+	name     string      // protocols on this interval, "" for the default
+	running  atomic.Bool // a round of this group is in progress
+}
+
+// scheduleGroups splits the servers by their effective continuous interval:
+// the -interval-<protocol> override for their protocol, or interval. Groups
+// are ordered from the shortest interval.
+func (m *Monitor) scheduleGroups(interval time.Duration) []*scheduleGroup {
+	interval = m.clampInterval(interval)
+	intervals := make(map[string]time.Duration, len(m.protocolIntervals))
+	protocols := make(map[time.Duration][]string)
+	for protocol, d := range m.protocolIntervals {
+		d = m.clampInterval(d)
+		intervals[protocol] = d
+		protocols[d] = append(protocols[d], protocol)
+	}
+
+	byInterval := make(map[time.Duration]*scheduleGroup)
+	var groups []*scheduleGroup
+	for _, server := range m.servers {
+		d, ok := intervals[server.Protocol]
+		if !ok {
+			d = interval
+		}
+		group, ok := byInterval[d]
+		if !ok {
+			group = &scheduleGroup{interval: d}
+			if d != interval {
+				sort.Strings(protocols[d])
+				group.name = strings.Join(protocols[d], ", ")
+			}
+			byInterval[d] = group
+			groups = append(groups, group)
+		}
+		group.servers = append(group.servers, server)
+	}
+	if len(groups) == 0 {
+		groups = append(groups, &scheduleGroup{interval: interval})
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i].interval < groups[j].interval })
+	return groups
+}
+
+// describeSchedule summarizes the groups for the startup line, e.g.
+// "30s; tcp: 10s" when tcp checks have their own interval.
+func describeSchedule(groups []*scheduleGroup) string {
+	var parts []string
+	for _, group := range groups {
+		if group.name == "" {
+			parts = append([]string{group.interval.String()}, parts...)
+		} else {
+			parts = append(parts, fmt.Sprintf("%s: %v", group.name, group.interval))
+		}
+	}
+	return strings.Join(parts, "; ")
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+const redactedValue = "[REDACTED]"
+
+// readSecretFile reads a secret mounted as a file (Docker/Kubernetes
+// secrets), dropping the trailing newline most tools leave behind.
+func readSecretFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read secret file: %v", err)
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+// resolveSecrets replaces PasswordFile and "@/path" header, cookie and login
+// form values with the contents of the referenced files, remembering which
+// header values were secret so they can be redacted from reports.
+func resolveSecrets(server *ServerConfig) error {
+	if server.PasswordFile != "" {
+		password, err := readSecretFile(server.PasswordFile)
+		if err != nil {
+			return err
+		}
+		server.Password = password
+	}
+
+	for name, value := range server.Headers {
+		if !strings.HasPrefix(value, "@") {
+			continue
+		}
+		secret, err := readSecretFile(strings.TrimPrefix(value, "@"))
+		if err != nil {
+			return fmt.Errorf("header %s: %v", name, err)
+		}
+		server.Headers[name] = secret
+		server.secretHeaders = append(server.secretHeaders, name)
+	}
+
+	if err := resolveSecretValues(server.Cookies); err != nil {
+		return fmt.Errorf("cookie %v", err)
+	}
+	if server.Login != nil {
+		if err := resolveSecretValues(server.Login.Form); err != nil {
+			return fmt.Errorf("login field %v", err)
+		}
+	}
+	return nil
+}
+
+// resolveSecretValues replaces "@/path" values in place with the contents
+// of the referenced files.
+func resolveSecretValues(values map[string]string) error {
+	for name, value := range values {
+		if !strings.HasPrefix(value, "@") {
+			continue
+		}
+		secret, err := readSecretFile(strings.TrimPrefix(value, "@"))
+		if err != nil {
+			return fmt.Errorf("%s: %v", name, err)
+		}
+		values[name] = secret
+	}
+	return nil
+}
+
+// redactedValues returns a copy of values with every value redacted.
+func redactedValues(values map[string]string) map[string]string {
+	if values == nil {
+		return nil
+	}
+	redacted := make(map[string]string, len(values))
+	for name := range values {
+		redacted[name] = redactedValue
+	}
+	return redacted
+}
+
+// redacted returns a copy of the config that is safe to log or write to a
+// report.
+func (s ServerConfig) redacted() ServerConfig {
+	if s.Password != "" {
+		s.Password = redactedValue
+	}
+	if len(s.secretHeaders) > 0 {
+		headers := make(map[string]string, len(s.Headers))
+		for name, value := range s.Headers {
+			headers[name] = value
+		}
+		for _, name := range s.secretHeaders {
+			headers[name] = redactedValue
+		}
+		s.Headers = headers
+	}
+	// Session cookies and login forms are credentials, however they were given
+	s.Cookies = redactedValues(s.Cookies)
+	if s.Login != nil {
+		login := *s.Login
+		login.Form = redactedValues(login.Form)
+		s.Login = &login
+	}
+	return s
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+)
+
+// RunSelfTest checks ephemeral local TCP and HTTP listeners, plus a closed
+// port, and verifies each comes back with the expected status. It returns
+// true if every case passed.
+func (m *Monitor) RunSelfTest() bool {
+	targets, stop, err := startLoopbackTargets()
+	if err != nil {
+		fmt.Printf("FAIL: %v\n", err)
+		return false
+	}
+	defer stop()
+
+	// Grab a free port and release it so nothing is listening there
+	closedListener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		fmt.Printf("FAIL: cannot reserve closed port: %v\n", err)
+		return false
+	}
+	closedPort := closedListener.Addr().(*net.TCPAddr).Port
+	closedListener.Close()
+
+	expected := map[string]string{
+		"selftest-tcp":    "UP",
+		"selftest-http":   "UP",
+		"selftest-closed": "DOWN",
+	}
+	m.servers = append(targets,
+		ServerConfig{Name: "selftest-closed", Host: "127.0.0.1", Port: closedPort, Protocol: "tcp", Timeout: 2})
+
+	passed := true
+	results := m.RunCheck()
+	fmt.Println()
+	for _, result := range results {
+		want := expected[result.Server.Name]
+		if result.Status == want {
+			fmt.Printf("PASS: %s is %s\n", result.Server.Name, result.Status)
+		} else {
+			fmt.Printf("FAIL: %s is %s, expected %s\n", result.Server.Name, result.Status, want)
+			passed = false
+		}
+	}
+	if len(results) != len(expected) {
+		fmt.Printf("FAIL: got %d results, expected %d\n", len(results), len(expected))
+		passed = false
+	}
+	return passed
+}
+
+// startLoopbackTargets starts ephemeral local TCP and HTTP listeners and
+// returns servers pointing at them ("selftest-tcp" and "selftest-http"),
+// along with a function that shuts them down.
+func startLoopbackTargets() ([]ServerConfig, func(), error) {
+	tcpListener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot start TCP listener: %v", err)
+	}
+	go func() {
+		for {
+			conn, err := tcpListener.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+
+	httpListener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		tcpListener.Close()
+		return nil, nil, fmt.Errorf("cannot start HTTP listener: %v", err)
+	}
+	httpServer := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, "ok")
+	})}
+	go httpServer.Serve(httpListener)
+
+	servers := []ServerConfig{
+		{Name: "selftest-tcp", Host: "127.0.0.1", Port: tcpListener.Addr().(*net.TCPAddr).Port, Protocol: "tcp", Timeout: 2},
+		{Name: "selftest-http", Host: "127.0.0.1", Port: httpListener.Addr().(*net.TCPAddr).Port, Protocol: "http", Timeout: 2},
+	}
+	stop := func() {
+		tcpListener.Close()
+		httpServer.Close()
+	}
+	return servers, stop, nil
+}
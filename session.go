@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// LoginConfig is a login step run before an HTTP check of a page that needs
+// a session: the form is POSTed to Path and the cookies the server sets are
+// sent with the check request.
+type LoginConfig struct {
+	Path string            `json:"path"`
+	Form map[string]string `json:"form"` // values of the form "@/path" are read from files
+}
+
+// requestPath is the path an HTTP check requests, "/" by default.
+func requestPath(path string) string {
+	if path == "" {
+		return "/"
+	}
+	if !strings.HasPrefix(path, "/") {
+		return "/" + path
+	}
+	return path
+}
+
+// login runs server.Login with client, whose cookie jar keeps the session
+// cookies for the check request that follows.
+func login(ctx context.Context, client *http.Client, base string, server ServerConfig) error {
+	form := url.Values{}
+	for name, value := range server.Login.Form {
+		form.Set(name, value)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, base+requestPath(server.Login.Path),
+		strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("login failed: %v", err)
+	}
+	if server.ServerName != "" {
+		req.Host = server.ServerName
+	}
+	for name, value := range server.Headers {
+		req.Header.Set(name, value)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("login failed: %v", err)
+	}
+	io.Copy(io.Discard, io.LimitReader(resp.Body, payloadReadBytes))
+	resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("login failed: HTTP %d", resp.StatusCode)
+	}
+	return nil
+}
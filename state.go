@@ -0,0 +1,192 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// serverState tracks a server's debounced status across rounds in
+// continuous mode. A raw result only changes Status once it has been seen
+// RiseThreshold (for UP) or FallThreshold (for DOWN) times in a row.
+type serverState struct {
+	Status    string    // confirmed status after applying thresholds
+	LastCheck string    // raw status of the most recent check
+	Streak    int       // consecutive checks with the LastCheck status
+	Since     time.Time // when Status was confirmed
+	LastError string    // error of the most recent failed check
+	LastAlert time.Time // when the last state change alert was sent
+
+	latencies    latencyWindow // response times of successful checks
+	P95          int64         // rolling p95 over the latency window, ms
+	LatencyAlert bool          // whether the p95 is above LatencyP95Ms
+
+	statuses statusWindow // raw statuses of recent checks
+
+	// Session statistics, for the summary printed on shutdown
+	Checks        int           // checks seen, excluding PAUSED and SKIPPED
+	UpChecks      int           // of which UP or DEGRADED
+	Transitions   int           // confirmed state changes
+	LongestOutage time.Duration // longest confirmed DOWN period that ended
+}
+
+type ServerStats struct {
+	Name      string `json:"name"`
+	Status    string `json:"status"`
+	LastCheck string `json:"last_check"`
+	Streak    int    `json:"streak"`
+	P95Ms     int64  `json:"p95_ms"`
+	History   string `json:"history"` // recent statuses, oldest first: ▁ UP, ▄ DEGRADED, █ DOWN
+}
+
+func threshold(n int) int {
+	if n < 1 {
+		return 1
+	}
+	return n
+}
+
+// updateState feeds a result into the server's state machine and prints a
+// line when the confirmed status or latency alert changes.
+func (m *Monitor) updateState(result HealthResult) {
+	if result.Status == "PAUSED" || result.Status == "SKIPPED" {
+		// Paused and skipped servers keep their state and never alert
+		return
+	}
+
+	if result.Status == "DOWN" && result.Timestamp.Before(m.graceUntil) {
+		// Targets started alongside the monitor may not be ready yet; DOWNs
+		// in the grace period neither set the initial state nor alert
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	state, ok := m.states[result.Server.Name]
+	if !ok {
+		// The first check establishes the initial state without a transition
+		state = &serverState{
+			Status:    result.Status,
+			LastCheck: result.Status,
+			Streak:    1,
+			Since:     result.Timestamp,
+			LastError: result.Error,
+		}
+		m.states[result.Server.Name] = state
+	} else {
+		m.advanceStatus(state, result)
+	}
+	state.statuses.add(result.Status)
+	state.Checks++
+	if result.up() {
+		state.UpChecks++
+	}
+	m.updateLatency(state, result)
+}
+
+func (m *Monitor) advanceStatus(state *serverState, result HealthResult) {
+	name := result.Server.Name
+	if result.Error != "" {
+		state.LastError = result.Error
+	}
+	if result.Status == state.LastCheck {
+		state.Streak++
+	} else {
+		state.LastCheck = result.Status
+		state.Streak = 1
+	}
+
+	if state.LastCheck == state.Status {
+		return
+	}
+
+	needed := threshold(result.Server.FallThreshold)
+	if state.LastCheck == "UP" {
+		needed = threshold(result.Server.RiseThreshold)
+	}
+	if state.Streak < needed {
+		fmt.Fprintf(m.out, "Pending: %s %s (%d/%d consecutive checks)\n",
+			name, state.LastCheck, state.Streak, needed)
+		return
+	}
+
+	fmt.Fprintf(m.out, "State change: %s %s -> %s (after %d consecutive checks)\n",
+		name, state.Status, state.LastCheck, state.Streak)
+
+	alert := Alert{
+		Server:    result.Server,
+		Status:    state.LastCheck,
+		Previous:  state.Status,
+		Error:     result.Error,
+		Timestamp: result.Timestamp,
+
+		Annotations: result.Server.Annotations,
+	}
+	if state.Status == "DOWN" {
+		outage := result.Timestamp.Sub(state.Since)
+		state.LongestOutage = max(state.LongestOutage, outage)
+		alert.Downtime = outage.Round(time.Second)
+		alert.Error = state.LastError
+	}
+	m.sendAlert(alert)
+	state.LastAlert = result.Timestamp
+	m.history.add(AlertEvent{
+		Server:    name,
+		From:      state.Status,
+		To:        state.LastCheck,
+		Timestamp: result.Timestamp,
+	})
+
+	state.Status = state.LastCheck
+	state.Since = result.Timestamp
+	state.Transitions++
+}
+
+// updateLatency records the response time of a successful check and raises
+// or clears the latency alert based on the rolling p95.
+func (m *Monitor) updateLatency(state *serverState, result HealthResult) {
+	if !result.up() {
+		return
+	}
+	state.latencies.add(result.Timestamp, result.ResponseTime)
+
+	p95, samples := state.latencies.percentile(95, result.Timestamp.Add(-m.latencyWindow))
+	state.P95 = p95
+
+	limit := int64(result.Server.LatencyP95Ms)
+	if limit <= 0 || samples < minLatencySamples {
+		return
+	}
+	if p95 > limit && !state.LatencyAlert {
+		state.LatencyAlert = true
+		fmt.Fprintf(m.out, "Latency alert: %s p95 %dms > %dms over the last %v\n",
+			result.Server.Name, p95, limit, m.latencyWindow)
+	} else if p95 <= limit && state.LatencyAlert {
+		state.LatencyAlert = false
+		fmt.Fprintf(m.out, "Latency recovered: %s p95 %dms <= %dms over the last %v\n",
+			result.Server.Name, p95, limit, m.latencyWindow)
+	}
+}
+
+// Stats returns a snapshot of the per-server state in continuous mode.
+func (m *Monitor) Stats() []ServerStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var stats []ServerStats
+	for _, server := range m.servers {
+		state, ok := m.states[server.Name]
+		if !ok {
+			continue
+		}
+		stats = append(stats, ServerStats{
+			Name:      server.Name,
+			Status:    state.Status,
+			LastCheck: state.LastCheck,
+			Streak:    state.Streak,
+			P95Ms:     state.P95,
+			History:   state.statuses.sparkline(),
+		})
+	}
+	return stats
+}
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// StartStatusServer serves the monitor's state over HTTP on addr:
+//
+//	GET  /status                 per-server stats and pause state
+//	POST /pause[?server=name]    pause all checks, or just one server
+//	POST /resume[?server=name]   resume all checks, or just one server
+//	POST /check[?tag=name]       run a check round now and return the results
+//	GET  /alerts[?limit=n]       recent state changes, newest first
+//	GET  /metrics                last round's results in Prometheus format
+func (m *Monitor) StartStatusServer(addr string) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to start status server: %v", err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/status", m.handleStatus)
+	mux.HandleFunc("/pause", m.handlePause(true))
+	mux.HandleFunc("/resume", m.handlePause(false))
+	mux.HandleFunc("/check", m.handleCheck)
+	mux.HandleFunc("/alerts", m.handleAlerts)
+	mux.HandleFunc("/metrics", m.handleMetrics)
+
+	go func() {
+		if err := http.Serve(listener, mux); err != nil {
+			log.Printf("Status server stopped: %v", err)
+		}
+	}()
+	fmt.Printf("Status server listening on %s\n", listener.Addr())
+	return nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func (m *Monitor) handleStatus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	paused, pausedServers := m.pauseState()
+	writeJSON(w, http.StatusOK, struct {
+		Paused        bool          `json:"paused"`
+		PausedServers []string      `json:"paused_servers"`
+		Servers       []ServerStats `json:"servers"`
+	}{
+		Paused:        paused,
+		PausedServers: pausedServers,
+		Servers:       m.Stats(),
+	})
+}
+
+func (m *Monitor) handlePause(pause bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		name := r.URL.Query().Get("server")
+		if name != "" && !m.hasServer(name) {
+			http.Error(w, fmt.Sprintf("unknown server %q", name), http.StatusNotFound)
+			return
+		}
+		m.setPaused(name, pause)
+
+		action := "Resumed"
+		if pause {
+			action = "Paused"
+		}
+		target := "all checks"
+		if name != "" {
+			target = name
+		}
+		fmt.Fprintf(m.out, "%s %s via status endpoint\n", action, target)
+
+		paused, pausedServers := m.pauseState()
+		writeJSON(w, http.StatusOK, struct {
+			Paused        bool     `json:"paused"`
+			PausedServers []string `json:"paused_servers"`
+		}{paused, pausedServers})
+	}
+}
+
+func (m *Monitor) handleAlerts(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	limit := 0
+	if value := r.URL.Query().Get("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 {
+			http.Error(w, fmt.Sprintf("invalid limit %q", value), http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+	writeJSON(w, http.StatusOK, m.Alerts(limit))
+}
+
+// handleCheck runs an on-demand round, optionally limited to servers with
+// the given tag, and responds once every check has finished.
+func (m *Monitor) handleCheck(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	servers := m.currentServers()
+	tag := r.URL.Query().Get("tag")
+	if tag != "" {
+		servers = serversWithTag(servers, tag)
+		if len(servers) == 0 {
+			http.Error(w, fmt.Sprintf("no servers tagged %q", tag), http.StatusNotFound)
+			return
+		}
+	}
+
+	fmt.Fprintf(m.out, "\n--- On-demand check via status endpoint at %s ---\n", m.formatTime(time.Now()))
+	writeJSON(w, http.StatusOK, m.newReport(m.runRound(servers)))
+}
+
+func serversWithTag(servers []ServerConfig, tag string) []ServerConfig {
+	var tagged []ServerConfig
+	for _, server := range servers {
+		for _, t := range server.Tags {
+			if t == tag {
+				tagged = append(tagged, server)
+				break
+			}
+		}
+	}
+	return tagged
+}
+
+// currentServers returns a copy of the servers being monitored, which a
+// config reload may swap at any time.
+func (m *Monitor) currentServers() []ServerConfig {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]ServerConfig(nil), m.servers...)
+}
+
+func (m *Monitor) hasServer(name string) bool {
+	for _, server := range m.currentServers() {
+		if server.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// setPaused pauses or resumes one server, or everything when name is empty.
+func (m *Monitor) setPaused(name string, pause bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if name == "" {
+		m.paused = pause
+		if !pause {
+			m.pausedServers = make(map[string]bool)
+		}
+		return
+	}
+	if pause {
+		m.pausedServers[name] = true
+	} else {
+		delete(m.pausedServers, name)
+	}
+}
+
+func (m *Monitor) pauseState() (bool, []string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	names := make([]string, 0, len(m.pausedServers))
+	for name := range m.pausedServers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return m.paused, names
+}
+
+func (m *Monitor) isPaused() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.paused
+}
+
+func (m *Monitor) isServerPaused(name string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.pausedServers[name]
+}
@@ -0,0 +1,38 @@
+package main
+
+import "strings"
+
+// statusSamples bounds the per-server history of check statuses
+const statusSamples = 30
+
+// statusWindow is a fixed-size ring buffer of recent check statuses.
+type statusWindow struct {
+	statuses [statusSamples]string
+	next     int
+	count    int
+}
+
+func (w *statusWindow) add(status string) {
+	w.statuses[w.next] = status
+	w.next = (w.next + 1) % statusSamples
+	if w.count < statusSamples {
+		w.count++
+	}
+}
+
+// sparkline renders the statuses oldest first, one block per check: low for
+// UP, half for DEGRADED and full for DOWN, so flapping stands out.
+func (w *statusWindow) sparkline() string {
+	var b strings.Builder
+	for i := 0; i < w.count; i++ {
+		switch w.statuses[(w.next-w.count+i+statusSamples)%statusSamples] {
+		case "UP":
+			b.WriteRune('▁')
+		case "DEGRADED":
+			b.WriteRune('▄')
+		default:
+			b.WriteRune('█')
+		}
+	}
+	return b.String()
+}
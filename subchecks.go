@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// SubCheck is one protocol/port pair of a composite server entry.
+type SubCheck struct {
+	Protocol string `json:"protocol"`
+	Port     int    `json:"port"`
+}
+
+// SubResult is the outcome of one sub-check of a composite server.
+type SubResult struct {
+	Protocol     string `json:"protocol"`
+	Port         int    `json:"port"`
+	Status       string `json:"status"`
+	ResponseTime int64  `json:"response_time"`
+	Error        string `json:"error,omitempty"`
+}
+
+// checkComposite runs all of a server's sub-checks concurrently. The server
+// is UP only if every sub-check is; otherwise it takes the worst sub-check
+// status, with the failing sub-checks listed in the error.
+func (m *Monitor) checkComposite(ctx context.Context, server ServerConfig) HealthResult {
+	results := make([]HealthResult, len(server.Checks))
+	var wg sync.WaitGroup
+	for i, sub := range server.Checks {
+		wg.Add(1)
+		go func(i int, sub SubCheck) {
+			defer wg.Done()
+			attempt := server
+			attempt.Checks = nil
+			attempt.Protocol = sub.Protocol
+			attempt.Port = sub.Port
+			results[i] = m.check(ctx, attempt)
+		}(i, sub)
+	}
+	wg.Wait()
+
+	combined := HealthResult{Server: server, Status: "UP"}
+	var errs []string
+	for i, result := range results {
+		sub := server.Checks[i]
+		combined.SubResults = append(combined.SubResults, SubResult{
+			Protocol:     sub.Protocol,
+			Port:         sub.Port,
+			Status:       result.Status,
+			ResponseTime: result.ResponseTime,
+			Error:        result.Error,
+		})
+		combined.ResponseTime = max(combined.ResponseTime, result.ResponseTime)
+		combined.Timestamp = result.Timestamp
+
+		switch result.Status {
+		case "UP":
+			continue
+		case "DOWN":
+			combined.Status = "DOWN"
+			combined.ErrorCategory = result.ErrorCategory
+		case "DEGRADED":
+			if combined.Status == "UP" {
+				combined.Status = "DEGRADED"
+				combined.ErrorCategory = result.ErrorCategory
+			}
+		}
+		errs = append(errs, fmt.Sprintf("%s/%d: %s", sub.Protocol, sub.Port, result.Error))
+	}
+	if len(errs) > 0 {
+		combined.Error = strings.Join(errs, "; ")
+	}
+	return combined
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"time"
+)
+
+// updateStates feeds a continuous or replayed round's results into the
+// per-server state and counts the round for the session summary. In a
+// monitor-side DNS failure the DNS failures are left out, so a broken local
+// resolver doesn't flood every server with DOWN alerts; failures on the
+// monitor's own file descriptor limit are left out likewise.
+func (m *Monitor) updateStates(results []HealthResult) {
+	_, _, outage := dnsOutage(results)
+	ignored := 0
+	for _, result := range results {
+		if outage && isDNSFailure(result) || result.ErrorCategory == "fd limit" {
+			ignored++
+			continue
+		}
+		m.updateState(result)
+	}
+	if ignored > 0 {
+		// Only modes that track state come here, so only they say so
+		fmt.Fprintf(m.out, "%d DOWN results caused by the monitor itself don't change server state or alert\n", ignored)
+	}
+	m.mu.Lock()
+	m.rounds++
+	m.mu.Unlock()
+}
+
+// printSessionSummary recaps a continuous session when it is stopped: its
+// length, rounds and checks, then per server the share of UP checks, state
+// changes and the longest confirmed outage, including one still ongoing.
+func (m *Monitor) printSessionSummary(w io.Writer) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.rounds == 0 {
+		return
+	}
+
+	checks, transitions, width := 0, 0, 0
+	for _, server := range m.servers {
+		if state, ok := m.states[server.Name]; ok {
+			checks += state.Checks
+			transitions += state.Transitions
+			width = max(width, len(server.Name))
+		}
+	}
+	fmt.Fprintf(w, "\nSession summary: %v, %d rounds, %d checks, %d state changes\n",
+		time.Since(m.started).Round(time.Second), m.rounds, checks, transitions)
+
+	now := time.Now()
+	for _, server := range m.servers {
+		state, ok := m.states[server.Name]
+		if !ok || state.Checks == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "  %-*s %6.2f%% up, %d state changes", width, server.Name,
+			float64(state.UpChecks)/float64(state.Checks)*100, state.Transitions)
+		longest, ongoing := state.LongestOutage, false
+		if state.Status == "DOWN" && now.Sub(state.Since) > longest {
+			longest, ongoing = now.Sub(state.Since), true
+		}
+		if longest > 0 {
+			fmt.Fprintf(w, ", longest outage %v", longest.Round(time.Second))
+			if ongoing {
+				fmt.Fprint(w, " (ongoing)")
+			}
+		}
+		fmt.Fprintln(w)
+	}
+}
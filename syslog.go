@@ -0,0 +1,59 @@
+//go:build !windows
+
+package main
+
+import (
+	"fmt"
+	"log/syslog"
+	"strings"
+)
+
+// syslogSink sends check results and alerts to a syslog daemon.
+type syslogSink struct {
+	w *syslog.Writer
+}
+
+// newSyslogSink connects to the local syslog daemon for "local", or to a
+// remote one given as udp://host:port or tcp://host:port.
+func newSyslogSink(spec string) (*syslogSink, error) {
+	network, addr := "", ""
+	if spec != "local" {
+		var ok bool
+		network, addr, ok = strings.Cut(spec, "://")
+		if !ok || (network != "udp" && network != "tcp") || addr == "" {
+			return nil, fmt.Errorf("invalid -syslog %q: want local, udp://host:port or tcp://host:port", spec)
+		}
+	}
+	w, err := syslog.Dial(network, addr, syslog.LOG_INFO|syslog.LOG_DAEMON, "health-monitor")
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to syslog: %v", err)
+	}
+	return &syslogSink{w: w}, nil
+}
+
+// LogResult logs a check result: DOWN at err, DEGRADED at warning and
+// everything else at info.
+func (s *syslogSink) LogResult(result HealthResult) {
+	line := fmt.Sprintf("%s %s %s:%d (%dms)", result.Status, result.Server.Name,
+		result.host(), result.Server.Port, result.ResponseTime)
+	if result.Error != "" {
+		line += " - " + result.Error
+	}
+
+	switch result.Status {
+	case "DOWN":
+		s.w.Err(line)
+	case "DEGRADED":
+		s.w.Warning(line)
+	default:
+		s.w.Info(line)
+	}
+}
+
+// Notify logs an alert at err when a server went DOWN, notice otherwise.
+func (s *syslogSink) Notify(alert Alert) error {
+	if alert.Status == "DOWN" {
+		return s.w.Err("alert: " + alert.Message)
+	}
+	return s.w.Notice("alert: " + alert.Message)
+}
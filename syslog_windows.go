@@ -0,0 +1,14 @@
+package main
+
+import "errors"
+
+// syslogSink is unavailable on Windows, which has no syslog daemon.
+type syslogSink struct{}
+
+func newSyslogSink(spec string) (*syslogSink, error) {
+	return nil, errors.New("-syslog is not supported on Windows")
+}
+
+func (s *syslogSink) LogResult(result HealthResult) {}
+
+func (s *syslogSink) Notify(alert Alert) error { return nil }
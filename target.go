@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+)
+
+// targetTimeout is the timeout, in seconds, of -target checks
+const targetTimeout = 5
+
+// targetPorts are the ports used for -target URLs that don't give one.
+var targetPorts = map[string]int{
+	"http":  80,
+	"https": 443,
+	"dns":   53,
+	"kafka": 9092,
+}
+
+// parseTarget turns a -target URL such as "tcp://8.8.8.8:53" or
+// "https://example.com/healthz" into a server named after it.
+func (m *Monitor) parseTarget(target string) (ServerConfig, error) {
+	u, err := url.Parse(target)
+	if err != nil {
+		return ServerConfig{}, fmt.Errorf("invalid -target %q: %v", target, err)
+	}
+	if _, ok := m.checkers[u.Scheme]; !ok {
+		return ServerConfig{}, fmt.Errorf("invalid -target %q: want protocol://host[:port][/path], with a protocol from -list-protocols", target)
+	}
+	if u.Hostname() == "" {
+		return ServerConfig{}, fmt.Errorf("invalid -target %q: no host", target)
+	}
+
+	server := ServerConfig{
+		Name:     target,
+		Host:     u.Hostname(),
+		Protocol: u.Scheme,
+		Timeout:  targetTimeout,
+	}
+	if port := u.Port(); port != "" {
+		server.Port, err = strconv.Atoi(port)
+		if err != nil {
+			return ServerConfig{}, fmt.Errorf("invalid -target %q: bad port %q", target, port)
+		}
+	} else if server.Port = targetPorts[u.Scheme]; server.Port == 0 {
+		return ServerConfig{}, fmt.Errorf("invalid -target %q: %s targets need a port", target, u.Scheme)
+	}
+	if u.Scheme == "http" || u.Scheme == "https" {
+		server.Path = u.RequestURI()
+	}
+	return server, nil
+}
+
+// parseTargets parses every -target, rejecting the same target twice.
+func (m *Monitor) parseTargets(targets []string) ([]ServerConfig, error) {
+	seen := make(map[string]bool)
+	var servers []ServerConfig
+	for _, target := range targets {
+		if seen[target] {
+			return nil, fmt.Errorf("duplicate -target %q", target)
+		}
+		seen[target] = true
+		server, err := m.parseTarget(target)
+		if err != nil {
+			return nil, err
+		}
+		servers = append(servers, server)
+	}
+	return servers, nil
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"crypto/tls"
+	"fmt"
+	"strings"
+)
+
+// peerCertificate returns the issuer and subject alternative names (DNS
+// names and IPs) of the certificate a TLS server presented.
+func peerCertificate(state *tls.ConnectionState) (issuer string, sans []string) {
+	if state == nil || len(state.PeerCertificates) == 0 {
+		return "", nil
+	}
+	cert := state.PeerCertificates[0]
+	sans = append(sans, cert.DNSNames...)
+	for _, ip := range cert.IPAddresses {
+		sans = append(sans, ip.String())
+	}
+	return cert.Issuer.String(), sans
+}
+
+// matchCertificate checks the peer certificate against ExpectIssuer, a
+// substring of the issuer's distinguished name (e.g. "Let's Encrypt" or
+// "CN=R11"), and ExpectSAN, a name that must be among its SANs.
+func matchCertificate(server ServerConfig, issuer string, sans []string) error {
+	if issuer == "" && len(sans) == 0 {
+		return fmt.Errorf("no TLS certificate (expect_issuer/expect_san need https)")
+	}
+	if server.ExpectIssuer != "" && !strings.Contains(issuer, server.ExpectIssuer) {
+		return fmt.Errorf("issuer %q does not contain %q", issuer, server.ExpectIssuer)
+	}
+	if server.ExpectSAN != "" {
+		for _, san := range sans {
+			if strings.EqualFold(san, server.ExpectSAN) {
+				return nil
+			}
+		}
+		return fmt.Errorf("%q not among the certificate's SANs %v", server.ExpectSAN, sans)
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	tuiEnterScreen = "\033[?1049h\033[?25l" // alternate screen, hide cursor
+	tuiLeaveScreen = "\033[?25h\033[?1049l" // show cursor, main screen
+	tuiClear       = "\033[H\033[2J"
+
+	// sparklineWidth is how many recent response times each row plots
+	sparklineWidth = 30
+)
+
+var sparkLevels = []rune("▁▂▃▄▅▆▇█")
+
+// sparkline scales values between their min and max onto block characters.
+func sparkline(values []int64) string {
+	if len(values) == 0 {
+		return ""
+	}
+	lo, hi := values[0], values[0]
+	for _, v := range values {
+		if v < lo {
+			lo = v
+		}
+		if v > hi {
+			hi = v
+		}
+	}
+
+	var b strings.Builder
+	for _, v := range values {
+		level := 0
+		if hi > lo {
+			level = int((v - lo) * int64(len(sparkLevels)-1) / (hi - lo))
+		}
+		b.WriteRune(sparkLevels[level])
+	}
+	return b.String()
+}
+
+// StartTUI runs continuous monitoring as a full-screen dashboard that is
+// redrawn after every round, until interrupted.
+func (m *Monitor) StartTUI(interval time.Duration) {
+	// Round output would scroll the dashboard away
+	m.out = io.Discard
+	m.useColor = true
+
+	fmt.Print(tuiEnterScreen)
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+
+	interval = m.clampInterval(interval)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		results := m.RunCheck()
+		m.updateStates(results)
+		m.renderTUI(results, interval)
+
+		select {
+		case <-ticker.C:
+		case <-signals:
+			fmt.Print(tuiLeaveScreen)
+			m.saveStateFile()
+			// Round output is discarded, but the recap belongs on the terminal
+			m.printSessionSummary(os.Stdout)
+			return
+		}
+	}
+}
+
+func (m *Monitor) renderTUI(results []HealthResult, interval time.Duration) {
+	byName := make(map[string]HealthResult, len(results))
+	up := 0
+	for _, result := range results {
+		byName[result.Server.Name] = result
+		if result.up() {
+			up++
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString(tuiClear)
+	fmt.Fprintf(&b, "Server Health Monitor - %s - every %v - %d UP, %d DOWN   (Ctrl+C to quit)\n\n",
+		m.formatTime(time.Now()), interval, up, len(results)-up)
+	fmt.Fprintf(&b, "%-8s %-24s %-30s %8s %8s  %-*s  %s\n",
+		"STATUS", "NAME", "TARGET", "RESP", "P95", statusSamples, "CHECKS", "RESPONSE TIMES")
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, server := range m.servers {
+		result, ok := byName[server.Name]
+		if !ok {
+			continue
+		}
+		var p95 int64
+		var checks, history string
+		if state, ok := m.states[server.Name]; ok {
+			p95 = state.P95
+			checks = state.statuses.sparkline()
+			history = sparkline(state.latencies.recent(sparklineWidth))
+		}
+		// Pad by rune count, as the block characters are multi-byte
+		checks += strings.Repeat(" ", statusSamples-utf8.RuneCountInString(checks))
+		target := fmt.Sprintf("%s:%d", result.host(), server.Port)
+		line := fmt.Sprintf("%-8s %-24.24s %-30.30s %6dms %6dms  %s  %s",
+			result.Status, server.Name, target, result.ResponseTime, p95, checks, history)
+		b.WriteString(m.colorize(result.Status, line))
+		b.WriteString("\n")
+	}
+
+	fmt.Print(b.String())
+}
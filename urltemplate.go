@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+)
+
+var urlPlaceholder = regexp.MustCompile(`\{(\w+)\}`)
+
+// expandURL fills in the placeholders of a server's URL template, e.g.
+// "https://{host}:{port}/health?region={region}". Placeholders name one of
+// the server's fields (name, host, port, protocol, path, group) or one of
+// its annotations; fields win over annotations of the same name.
+func expandURL(server ServerConfig) (*url.URL, error) {
+	fields := map[string]string{
+		"name":     server.Name,
+		"host":     server.Host,
+		"port":     strconv.Itoa(server.Port),
+		"protocol": server.Protocol,
+		"path":     requestPath(server.Path),
+		"group":    server.Group,
+	}
+
+	var err error
+	expanded := urlPlaceholder.ReplaceAllStringFunc(server.URL, func(placeholder string) string {
+		key := placeholder[1 : len(placeholder)-1]
+		if value, ok := fields[key]; ok {
+			return value
+		}
+		if value, ok := server.Annotations[key]; ok {
+			return value
+		}
+		if err == nil {
+			err = fmt.Errorf("url: unknown placeholder %s", placeholder)
+		}
+		return placeholder
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	u, err := url.Parse(expanded)
+	if err != nil {
+		return nil, fmt.Errorf("url: %v", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" || u.Host == "" {
+		return nil, fmt.Errorf("url: %q is not an http(s) URL", expanded)
+	}
+	return u, nil
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+const (
+	// watchPollInterval is how often -watch-file checks the file for
+	// changes. Polling rather than fsnotify keeps the monitor free of a
+	// platform-specific dependency, and unlike inotify it keeps working when
+	// the file is replaced by a rename or lives on a network filesystem.
+	watchPollInterval = 250 * time.Millisecond
+	// watchDebounce is how long the file must stay unchanged before a round
+	// runs, so a burst of writes (e.g. a deploy script) triggers one round
+	watchDebounce = 500 * time.Millisecond
+)
+
+// fileStamp identifies a version of a watched file.
+type fileStamp struct {
+	exists  bool
+	modTime time.Time
+	size    int64
+}
+
+func statFile(path string) fileStamp {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fileStamp{}
+	}
+	return fileStamp{exists: true, modTime: info.ModTime(), size: info.Size()}
+}
+
+// WatchFile runs a check round each time path is created or modified,
+// instead of on a timer, so an external process can drive checks by
+// touching a file. The file is polled, which works the same on every
+// platform and filesystem, including network mounts.
+func (m *Monitor) WatchFile(path string) {
+	fmt.Fprintf(m.out, "Watching %s; a check round runs each time it changes\n", path)
+	fmt.Fprintln(m.out, "Press Ctrl+C to stop...")
+
+	ticker := time.NewTicker(watchPollInterval)
+	defer ticker.Stop()
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	group := &scheduleGroup{servers: m.servers}
+	last := statFile(path)
+	var changedAt time.Time // zero when no change is pending
+	for {
+		select {
+		case <-ticker.C:
+			if stamp := statFile(path); stamp != last {
+				last = stamp
+				changedAt = time.Now()
+				continue
+			}
+			// A change during a round is kept pending until it finishes
+			if changedAt.IsZero() || time.Since(changedAt) < watchDebounce || group.running.Load() {
+				continue
+			}
+			changedAt = time.Time{}
+			m.startRound(group)
+		case <-signals:
+			fmt.Fprintln(m.out, "\nStopping file watch")
+			m.saveStateFile()
+			m.printSessionSummary(m.out)
+			return
+		}
+	}
+}
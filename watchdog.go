@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// errorRateWatchdog is the -fail-on-error-rate check of continuous mode: it
+// trips once more servers than its threshold have been DOWN for the whole
+// -for window, so a supervisor notices a sustained fleet-wide failure.
+type errorRateWatchdog struct {
+	threshold downThreshold
+	window    time.Duration
+	latest    map[string]string // last status of each checked server
+	since     time.Time         // when the threshold was first exceeded, zero while below
+	tripped   chan error
+}
+
+func newErrorRateWatchdog(threshold downThreshold, window time.Duration) *errorRateWatchdog {
+	return &errorRateWatchdog{
+		threshold: threshold,
+		window:    window,
+		latest:    make(map[string]string),
+		tripped:   make(chan error, 1),
+	}
+}
+
+// observeErrorRate feeds a round into the -fail-on-error-rate watchdog.
+// Servers are counted with their latest status, so with -interval-<protocol>
+// the rate still covers the whole fleet; PAUSED and SKIPPED servers are not
+// counted at all.
+func (m *Monitor) observeErrorRate(results []HealthResult) {
+	w := m.watchdog
+	if w == nil {
+		return
+	}
+	for _, result := range results {
+		if result.Status == "PAUSED" || result.Status == "SKIPPED" {
+			delete(w.latest, result.Server.Name)
+		} else {
+			w.latest[result.Server.Name] = result.Status
+		}
+	}
+	down := 0
+	for _, status := range w.latest {
+		if status == "DOWN" {
+			down++
+		}
+	}
+
+	now := time.Now()
+	if !w.threshold.exceeded(down, len(w.latest)) {
+		if !w.since.IsZero() {
+			fmt.Fprintf(m.out, "Error rate recovered: %.1f%% DOWN (%d of %d), threshold: %s\n",
+				downPercent(down, len(w.latest)), down, len(w.latest), w.threshold)
+		}
+		w.since = time.Time{}
+		return
+	}
+	if w.since.IsZero() {
+		w.since = now
+		fmt.Fprintf(m.out, "Warning: %.1f%% DOWN (%d of %d) exceeds -fail-on-error-rate %s; exiting if it lasts %v\n",
+			downPercent(down, len(w.latest)), down, len(w.latest), w.threshold, w.window)
+	}
+	if now.Sub(w.since) < w.window {
+		return
+	}
+	select {
+	case w.tripped <- fmt.Errorf("%.1f%% of servers DOWN (%d of %d), above %s for %v",
+		downPercent(down, len(w.latest)), down, len(w.latest), w.threshold, now.Sub(w.since).Round(time.Second)):
+	default:
+	}
+}
+
+// prune forgets servers that are no longer configured, e.g. after a config
+// reload, so their last status stops counting. A -resolve or -resolve-all
+// target, named "<server> (<family or address>)", goes with its server.
+func (w *errorRateWatchdog) prune(servers []ServerConfig) {
+	configured := make(map[string]bool, len(servers))
+	for _, server := range servers {
+		configured[server.Name] = true
+	}
+	for name := range w.latest {
+		server := name
+		if i := strings.LastIndex(name, " ("); i >= 0 && strings.HasSuffix(name, ")") {
+			server = name[:i]
+		}
+		if !configured[name] && !configured[server] {
+			delete(w.latest, name)
+		}
+	}
+}